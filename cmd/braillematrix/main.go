@@ -59,7 +59,7 @@ func Main() error {
 		}
 	}
 
-	page.SubDisplay(image.Rect(0, 0, 1, h)).Fill(string(0x28ff), display.Colors[8], color.Transparent)
+	page.Fill(image.Rect(0, 0, 1, h), string(rune(0x28ff)), display.Colors[8], color.Transparent)
 	braille.Draw(page, rb, img, image.ZP, color.White, color.Transparent)
 
 	var buf []byte
@@ -10,7 +10,7 @@ import (
 
 	"github.com/disintegration/imaging"
 	"github.com/kriskowal/cops/braille"
-	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/screen"
 	"github.com/kriskowal/cops/terminal"
 )
 
@@ -21,14 +21,17 @@ func main() {
 }
 
 func Main() error {
-	term := terminal.New(os.Stdout.Fd())
-	defer term.Restore()
-	term.SetRaw()
+	s, err := screen.New(terminal.New(os.Stdout.Fd()), os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
 
-	bounds, err := term.Bounds()
+	sync, err := terminal.QueryMode(os.Stdin, os.Stdout, terminal.SynchronizedOutput)
 	if err != nil {
 		return err
 	}
+	s.Synchronized = sync.Supported()
 
 	ticker := time.NewTicker(16 * time.Millisecond)
 
@@ -40,11 +43,7 @@ func Main() error {
 	}()
 
 	img := image.NewRGBA(image.Rect(0, 0, 1000, 1000))
-	var buf []byte
-	cur := display.Start
-	buf, cur = cur.Hide(buf)
-	buf, cur = cur.Home(buf)
-	buf, cur = cur.Clear(buf)
+	bounds := s.Bounds()
 
 Loop:
 	for {
@@ -62,14 +61,14 @@ Loop:
 		}
 
 		// Size that image down and write it in braille to the display.
-		dis := display.New(bounds)
+		s.Display().Clear(bounds)
 		bb := braille.Bounds(bounds)
 		bimg := imaging.Resize(img, bb.Dx(), bb.Dy(), imaging.Lanczos)
-		braille.Draw(dis, bounds, bimg, image.ZP, color.RGBA{191, 191, 127, 255}, color.Black)
+		braille.Draw(s.Display(), bounds, bimg, image.ZP, color.RGBA{191, 191, 127, 255}, color.Black)
 
-		buf, cur = display.Render(buf, cur, dis, display.Model24)
-		os.Stdout.Write(buf)
-		buf = buf[0:0]
+		if err := s.Flush(); err != nil {
+			return err
+		}
 
 		select {
 		case <-ticker.C:
@@ -80,11 +79,5 @@ Loop:
 
 	ticker.Stop()
 
-	buf, cur = cur.Home(buf)
-	buf, cur = cur.Clear(buf)
-	buf, cur = cur.Show(buf)
-	os.Stdout.Write(buf)
-	buf = buf[0:0]
-
 	return nil
 }
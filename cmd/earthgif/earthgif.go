@@ -11,6 +11,7 @@ import (
 
 	"github.com/disintegration/imaging"
 	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/screen"
 	"github.com/kriskowal/cops/terminal"
 )
 
@@ -21,41 +22,29 @@ func main() {
 }
 
 func Main() error {
-	term := terminal.New(os.Stdin.Fd())
-	defer term.Restore()
-	term.SetRaw()
-
-	data, err := Asset("earth.gif")
+	s, err := screen.New(terminal.New(os.Stdin.Fd()), os.Stdout)
 	if err != nil {
 		return err
 	}
+	defer s.Close()
 
-	bounds, err := term.Bounds()
+	imgs, err := decode()
 	if err != nil {
 		return err
 	}
 
-	r := bytes.NewReader(data)
-	imgs, err := gif.DecodeAll(r)
-	if len(imgs.Image) == 0 {
-		return fmt.Errorf("no frames")
+	// Ask whether the terminal can hold the screen still while each frame
+	// draws, before anything else reads keys.
+	sync, err := terminal.QueryMode(os.Stdin, os.Stdout, terminal.SynchronizedOutput)
+	if err != nil {
+		return err
 	}
+	s.Synchronized = sync.Supported()
 
-	front, back := display.New2(bounds)
-
-	front.Text.Fill(" ")
-	draw.Draw(front.Background, bounds, &image.Uniform{display.Colors[0]}, image.ZP, draw.Src)
-	draw.Draw(front.Foreground, bounds, &image.Uniform{display.Colors[0]}, image.ZP, draw.Src)
-
-	// Clear Home Hide
-	var buf []byte
-	cur := display.Start
-	buf, cur = cur.Hide(buf)
-	buf, cur = cur.Clear(buf)
-	buf, cur = cur.Home(buf)
+	blank(s.Display())
 
 	base := imgs.Image[0]
-	projection := projectCenterPreserveAspect(base.Bounds().Size(), bounds.Size())
+	projection := projectCenterPreserveAspect(base.Bounds().Size(), s.Bounds().Size())
 
 	// Await async keypress
 	keypress := make(chan byte, 1)
@@ -72,14 +61,12 @@ Loop:
 		img := imgs.Image[i]
 		// Resize image and draw onto display background
 		img2 := imaging.Resize(img, projection.Dx(), projection.Dy(), imaging.Lanczos)
-		draw.Draw(front.Background, projection, img2, img2.Bounds().Min, draw.Over)
+		draw.Draw(s.Display().Background, projection, img2, img2.Bounds().Min, draw.Over)
 
 		// Draw frame
-		buf, cur = display.RenderOver(buf, cur, front, back, display.Model24)
-		front, back = back, front
-		buf, cur = cur.Home(buf)
-		os.Stdout.Write(buf)
-		buf = buf[0:0]
+		if err := s.Flush(); err != nil {
+			return err
+		}
 
 		delay := time.Duration(imgs.Delay[i]) * time.Millisecond * 10
 		timer := time.NewTimer(delay)
@@ -94,16 +81,32 @@ Loop:
 		}
 	}
 
-	// Restore
-	buf, cur = cur.Home(buf)
-	buf, cur = cur.Clear(buf)
-	buf, cur = cur.Show(buf)
-	os.Stdout.Write(buf)
-	buf = buf[0:0]
-
 	return nil
 }
 
+// decode decodes the frames of the animation.
+func decode() (*gif.GIF, error) {
+	data, err := Asset("earth.gif")
+	if err != nil {
+		return nil, err
+	}
+	imgs, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(imgs.Image) == 0 {
+		return nil, fmt.Errorf("no frames")
+	}
+	return imgs, nil
+}
+
+// blank fills a display with black spaces, for the frames to draw over.
+func blank(d *display.Display) {
+	d.Text.Fill(" ")
+	draw.Draw(d.Background, d.Rect, &image.Uniform{display.Colors[0]}, image.ZP, draw.Src)
+	draw.Draw(d.Foreground, d.Rect, &image.Uniform{display.Colors[0]}, image.ZP, draw.Src)
+}
+
 func projectCenterPreserveAspect(inner, outer image.Point) image.Rectangle {
 	// Account for aspect of terminal cell
 	inner.X *= 2
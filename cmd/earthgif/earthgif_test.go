@@ -0,0 +1,95 @@
+package main
+
+import (
+	"image"
+	"image/draw"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/kriskowal/cops/display"
+)
+
+// BenchmarkRender renders the frames of the animation at a typical terminal
+// size, as Main does on a screen, reporting the bytes written per frame by
+// moving the cursor to each changed cell, by RenderOver, and by
+// RenderCompressed, so the savings of each can be measured.
+func BenchmarkRender(b *testing.B) {
+	imgs, err := decode()
+	if err != nil {
+		b.Fatal(err)
+	}
+	bounds := image.Rect(0, 0, 160, 48)
+	projection := projectCenterPreserveAspect(imgs.Image[0].Bounds().Size(), bounds.Size())
+	var frames []image.Image
+	for _, img := range imgs.Image {
+		frames = append(frames, imaging.Resize(img, projection.Dx(), projection.Dy(), imaging.Lanczos))
+	}
+
+	renders := []struct {
+		unit   string
+		render func(buf []byte, cur display.Cursor, over, under *display.Display, model display.Model) ([]byte, display.Cursor)
+	}{
+		{"unoptimized-bytes/frame", renderEachCell},
+		{"bytes/frame", display.RenderOver},
+		{"compressed-bytes/frame", func(buf []byte, cur display.Cursor, over, under *display.Display, model display.Model) ([]byte, display.Cursor) {
+			return display.RenderCompressed(buf, cur, over, under, model,
+				display.CompressErase|display.CompressRepeat|display.CompressEraseLine)
+		}},
+	}
+	type state struct {
+		front, back *display.Display
+		cur         display.Cursor
+		total       int
+	}
+	states := make([]state, len(renders))
+	for i := range states {
+		states[i].front, states[i].back = display.New2(bounds)
+		blank(states[i].front)
+		states[i].cur = display.Start
+	}
+
+	var buf []byte
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		img := frames[i%len(frames)]
+		for j, r := range renders {
+			s := &states[j]
+			draw.Draw(s.front.Background, projection, img, img.Bounds().Min, draw.Over)
+			buf, s.cur = r.render(buf, s.cur, s.front, s.back, display.Model24)
+			// As the screen does, keep the frame to draw the next over.
+			s.front, s.back = s.back, s.front
+			display.Draw(s.front, bounds, s.back, bounds.Min, draw.Src)
+			s.total += len(buf)
+			buf = buf[0:0]
+		}
+	}
+	for j, r := range renders {
+		b.ReportMetric(float64(states[j].total)/float64(b.N), r.unit)
+	}
+}
+
+// renderEachCell renders the cells that changed, moving the cursor to each
+// with Go, without the optimizations of RenderOver, for comparison.
+func renderEachCell(buf []byte, cur display.Cursor, over, under *display.Display, model display.Model) ([]byte, display.Cursor) {
+	for y := over.Rect.Min.Y; y < over.Rect.Max.Y; y++ {
+		for x := over.Rect.Min.X; x < over.Rect.Max.X; x++ {
+			ot, covered := over.GlyphAt(x, y)
+			if covered {
+				continue
+			}
+			ut, _ := under.GlyphAt(x, y)
+			_, of, ob := over.At(x, y)
+			oa := over.AttrAt(x, y)
+			_, uf, ub := under.At(x, y)
+			ua := under.AttrAt(x, y)
+			if ot == ut && of == uf && ob == ub && oa == ua {
+				continue
+			}
+			buf, cur = cur.Go(buf, image.Pt(x, y))
+			buf, cur = cur.SetAttr(buf, oa)
+			buf, cur = model.Render(buf, cur, of, ob)
+			buf, cur = cur.WriteGlyph(buf, ot)
+		}
+	}
+	return buf, cur
+}
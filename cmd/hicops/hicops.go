@@ -9,6 +9,7 @@ import (
 
 	"github.com/kriskowal/cops/display"
 	"github.com/kriskowal/cops/rectangle"
+	"github.com/kriskowal/cops/screen"
 	"github.com/kriskowal/cops/terminal"
 	"github.com/kriskowal/cops/text"
 )
@@ -20,18 +21,16 @@ func main() {
 }
 
 func Main() error {
-	term := terminal.New(os.Stdin.Fd())
-	defer term.Restore()
-	term.SetRaw()
-
-	bounds, err := term.Bounds()
+	s, err := screen.New(terminal.New(os.Stdin.Fd()), os.Stdout)
 	if err != nil {
 		return err
 	}
+	defer s.Close()
 
-	front := display.New(bounds)
+	bounds := s.Bounds()
+	front := s.Display()
 
-	front.Fill("/", color.RGBA{192, 0, 0, 255}, color.RGBA{30, 20, 40, 255})
+	front.Fill(bounds, "/", color.RGBA{192, 0, 0, 255}, color.RGBA{30, 20, 40, 255})
 
 	msg := "Press any key to continue..."
 	msgbox := text.Bounds(msg)
@@ -44,24 +43,12 @@ func Main() error {
 	text.Write(panel, inset, msg, display.Colors[7])
 	display.Draw(front, outset, panel, outset.Min, draw.Over)
 
-	var buf []byte
-	cur := display.Start
-	buf, cur = cur.Hide(buf)
-	buf, cur = cur.Clear(buf)
-	buf, cur = cur.Home(buf)
-	buf, cur = display.Render(buf, cur, front, display.Model24)
-	buf, cur = cur.Home(buf)
-	os.Stdout.Write(buf)
-	buf = buf[0:0]
+	if err := s.Flush(); err != nil {
+		return err
+	}
 
 	var input [1]byte
 	os.Stdin.Read(input[0:1])
 
-	buf, cur = cur.Home(buf)
-	buf, cur = cur.Clear(buf)
-	buf, cur = cur.Show(buf)
-	os.Stdout.Write(buf)
-	buf = buf[0:0]
-
 	return nil
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"time"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/screen"
+	"github.com/kriskowal/cops/terminal"
+	"github.com/kriskowal/cops/text"
+)
+
+// Shows a progress bar in two rows beneath the shell prompt, leaving the
+// finished bar in the scrollback, or, with the argument "-erase", erasing it.
+func main() {
+	if err := Main(); err != nil {
+		fmt.Printf("%v\n", err)
+	}
+}
+
+func Main() error {
+	size, err := terminal.New(os.Stdout.Fd()).Size()
+	if err != nil {
+		return err
+	}
+	width := size.X
+	if width > 60 {
+		width = 60
+	}
+
+	in := screen.NewInline(os.Stdout, image.Pt(width, 2))
+	finish := in.Close
+	if len(os.Args) > 1 && os.Args[1] == "-erase" {
+		finish = in.Erase
+	}
+	defer finish()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for i := 0; i <= 100; i++ {
+		d := in.Display()
+		bounds := d.Rect
+		d.Clear(bounds)
+		text.Write(d, image.Rect(0, 0, width, 1), fmt.Sprintf("Working... %d%%", i), display.Colors[7])
+		bar := bounds.Dx() * i / 100
+		d.Fill(image.Rect(0, 1, bar, 2), " ", display.Colors[7], display.Colors[2])
+		d.Fill(image.Rect(bar, 1, bounds.Dx(), 2), "·", display.Colors[8], display.Colors[0])
+		if err := in.Flush(); err != nil {
+			return err
+		}
+		<-ticker.C
+	}
+	return nil
+}
@@ -2,16 +2,19 @@ package main
 
 import (
 	"fmt"
-	"io"
+	"image"
 	"os"
 	"os/exec"
 
 	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/mux"
+	"github.com/kriskowal/cops/screen"
 	"github.com/kriskowal/cops/terminal"
-	"github.com/kriskowal/cops/vtio"
-	"github.com/pkg/term/termios"
 )
 
+// Runs each argument as a shell command in a pane of its own, side by side,
+// or htop alone.
+// Ctrl+] passes focus to the next pane, and vt exits when every program has.
 func main() {
 	if err := Main(); err != nil {
 		fmt.Printf("%v\n", err)
@@ -19,73 +22,93 @@ func main() {
 }
 
 func Main() error {
-	term := terminal.New(os.Stdin.Fd())
-	defer term.Restore()
-	term.SetRaw()
-
-	leader, follower, err := termios.Pty()
-	if err != nil {
-		return err
+	commands := os.Args[1:]
+	if len(commands) == 0 {
+		commands = []string{"htop"}
 	}
 
-	bounds, err := term.Bounds()
+	term := terminal.New(os.Stdin.Fd())
+	s, err := screen.New(term, os.Stdout)
 	if err != nil {
 		return err
 	}
+	defer s.Close()
+	s.Render = display.RenderScrolling
 
-	if err := terminal.SetSize(follower.Fd(), bounds.Max); err != nil {
-		return err
-	}
+	bounds := s.Bounds()
 
-	cmd := exec.Command("htop")
-	cmd.Stdin = follower
-	cmd.Stdout = follower
-	cmd.Stderr = follower
-	if err := cmd.Start(); err != nil {
-		return err
+	m := mux.New()
+	defer m.Close()
+	for i, command := range commands {
+		cmd := exec.Command("sh", "-c", command)
+		if _, err := m.Start(cmd, column(bounds, i, len(commands))); err != nil {
+			return err
+		}
 	}
 
-	vtw := vtio.NewDisplayWriter(bounds)
-	go io.Copy(vtw, leader)
-
-	front, back := display.New2(bounds)
-
-	var buf []byte
-	cur := display.Start
-	buf, cur = cur.Reset(buf)
-	buf, cur = cur.Home(buf)
-	buf, cur = cur.Clear(buf)
-	buf, cur = cur.Hide(buf)
-
-	// Wait for keypress
-	r := make(chan struct{}, 0)
+	// Forward keys to the focused pane.
 	go func() {
-		var rbuf [1]byte
-		os.Stdin.Read(rbuf[0:1])
-		close(r)
+		defer s.Recover()
+		var rbuf [256]byte
+		for {
+			n, err := os.Stdin.Read(rbuf[:])
+			if err != nil {
+				return
+			}
+			for _, b := range rbuf[:n] {
+				if b == 0x1d {
+					m.FocusNext()
+				} else {
+					m.Write([]byte{b})
+				}
+			}
+		}
 	}()
 
-DrawLoop:
 	for {
 		select {
-		case <-vtw.C():
-			vtw.Draw(front, bounds)
-			buf, cur = display.RenderOver(buf, cur, front, back, display.Model24)
-			front, back = back, front
-			// fmt.Printf("%q\r\n", buf)
-			os.Stdout.Write(buf)
-			buf = buf[0:0]
-		case <-r:
-			break DrawLoop
+		case <-m.C():
+			if exited(m) {
+				return nil
+			}
+			m.Draw(s.Display())
+			// Place the terminal's cursor where the focused program expects
+			// it.
+			if vc, ok := m.Cursor(); ok && vc.Visible {
+				s.ShowCursor(vc.Position, vc.Shape)
+			} else {
+				s.HideCursor()
+			}
+			if err := s.Flush(); err != nil {
+				return err
+			}
+		case <-s.Resized():
+			// Each program receives its own SIGWINCH and redraws.
+			bounds = s.Bounds()
+			panes := m.Panes()
+			for i, p := range panes {
+				if err := p.Resize(column(bounds, i, len(panes))); err != nil {
+					return err
+				}
+			}
 		}
 	}
+}
 
-	buf, cur = cur.Reset(buf)
-	buf, cur = cur.Home(buf)
-	buf, cur = cur.Clear(buf)
-	buf, cur = cur.Show(buf)
-	os.Stdout.Write(buf)
-	buf = buf[0:0]
+// column returns the ith of n columns of equal width spanning the bounds.
+func column(bounds image.Rectangle, i, n int) image.Rectangle {
+	w := bounds.Dx()
+	return image.Rect(bounds.Min.X+w*i/n, bounds.Min.Y, bounds.Min.X+w*(i+1)/n, bounds.Max.Y)
+}
 
-	return nil
+// exited returns whether every program has exited.
+func exited(m *mux.Mux) bool {
+	for _, p := range m.Panes() {
+		select {
+		case <-p.Done():
+		default:
+			return false
+		}
+	}
+	return true
 }
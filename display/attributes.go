@@ -0,0 +1,167 @@
+package display
+
+import (
+	"image"
+)
+
+// Attr is a set of text attributes for a cell, like bold or underline.
+// The zero value is plain text.
+type Attr uint8
+
+const (
+	// Bold renders text in bold or increased intensity.
+	Bold Attr = 1 << iota
+	// Italic renders text in italics.
+	Italic
+	// Underline underlines text.
+	Underline
+	// Blink causes text to blink, on terminals that still honor it.
+	Blink
+	// Reverse swaps the foreground and background colors.
+	Reverse
+	// Strikethrough crosses out text.
+	Strikethrough
+	// Dim renders text in decreased intensity.
+	Dim
+)
+
+// unknownAttr indicates that the attributes of the cursor are not known, so
+// the next text must be preceded by SGR sequences to turn every attribute
+// explicitly on or off.
+const unknownAttr Attr = 1 << 7
+
+// Attributes models the text attributes of every cell in a display. Like
+// images and textiles, the attributes layer is a thin header that can share
+// allocated memory with other attribute layers.
+type Attributes struct {
+	Attrs  []Attr
+	Stride int
+	Rect   image.Rectangle
+}
+
+// NewAttributes returns an attributes layer with the given rectangle.
+// As with images, the rectangle need not rest at the origin.
+func NewAttributes(r image.Rectangle) *Attributes {
+	w, h := r.Dx(), r.Dy()
+	return &Attributes{
+		Attrs:  make([]Attr, w*h),
+		Stride: w,
+		Rect:   r,
+	}
+}
+
+// Bounds returns the bounding box of the attributes layer.
+func (a *Attributes) Bounds() image.Rectangle {
+	return a.Rect
+}
+
+// At returns the attributes at a given point.
+func (a *Attributes) At(x, y int) Attr {
+	if !(image.Point{x, y}.In(a.Rect)) {
+		return 0
+	}
+	return a.Attrs[a.AttrsOffset(x, y)]
+}
+
+// Set overwrites the attributes at a point.
+func (a *Attributes) Set(x, y int, attr Attr) {
+	if !(image.Point{x, y}.In(a.Rect)) {
+		return
+	}
+	a.Attrs[a.AttrsOffset(x, y)] = attr
+}
+
+// Fill overwrites the attributes of every cell in the layer.
+func (a *Attributes) Fill(attr Attr) {
+	for y := a.Rect.Min.Y; y < a.Rect.Max.Y; y++ {
+		for x := a.Rect.Min.X; x < a.Rect.Max.X; x++ {
+			a.Set(x, y, attr)
+		}
+	}
+}
+
+// SubAttributes returns a region of the attributes layer, sharing the same
+// memory.
+func (a *Attributes) SubAttributes(r image.Rectangle) *Attributes {
+	r = r.Intersect(a.Rect)
+	if r.Empty() {
+		return &Attributes{}
+	}
+	i := a.AttrsOffset(r.Min.X, r.Min.Y)
+	return &Attributes{
+		Attrs:  a.Attrs[i:],
+		Stride: a.Stride,
+		Rect:   r,
+	}
+}
+
+// AttrsOffset is a utility for seeking a slice of the underlying attributes
+// starting at the given position within the allocation.
+func (a *Attributes) AttrsOffset(x, y int) int {
+	return (y-a.Rect.Min.Y)*a.Stride + (x - a.Rect.Min.X)
+}
+
+// attrCodes associates each attribute with the SGR codes that turn it on and
+// off.
+var attrCodes = []struct {
+	attr    Attr
+	on, off string
+}{
+	{Bold, "1", "22"},
+	{Italic, "3", "23"},
+	{Underline, "4", "24"},
+	{Blink, "5", "25"},
+	{Reverse, "7", "27"},
+	{Strikethrough, "9", "29"},
+	// Code 22 turns off both bold and dim, so renderAttr turns off dim by
+	// turning off bold.
+	{Dim, "2", ""},
+}
+
+// renderAttr appends a single SGR sequence that changes the terminal's text
+// attributes from one set to another, or nothing if they are the same.
+// If the former attributes are unknown, every attribute is explicitly turned
+// on or off.
+func renderAttr(buf []byte, from, to Attr) []byte {
+	if from == to {
+		return buf
+	}
+	off := from &^ to
+	on := to &^ from
+	if from&unknownAttr != 0 {
+		off = ^to &^ unknownAttr
+		on = to
+	}
+	if off&(Bold|Dim) != 0 {
+		// A single code turns off both bold and dim, so turn whichever
+		// remains back on.
+		off |= Bold
+		on |= to & (Bold | Dim)
+	}
+	start := len(buf)
+	for _, c := range attrCodes {
+		if off&c.attr != 0 && c.off != "" {
+			buf = appendSGRParam(buf, start, c.off)
+		}
+	}
+	for _, c := range attrCodes {
+		if on&c.attr != 0 {
+			buf = appendSGRParam(buf, start, c.on)
+		}
+	}
+	if len(buf) > start {
+		buf = append(buf, "m"...)
+	}
+	return buf
+}
+
+// appendSGRParam appends a parameter to an SGR sequence that begins at the
+// given offset of the buffer, opening the sequence for the first parameter.
+func appendSGRParam(buf []byte, start int, param string) []byte {
+	if len(buf) == start {
+		buf = append(buf, "\033["...)
+	} else {
+		buf = append(buf, ";"...)
+	}
+	return append(buf, param...)
+}
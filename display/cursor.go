@@ -4,7 +4,8 @@ import (
 	"image"
 	"image/color"
 	"strconv"
-	"unicode/utf8"
+
+	"github.com/kriskowal/cops/textile"
 )
 
 // Cursor models the known or unknown states of a cursor.
@@ -22,6 +23,11 @@ type Cursor struct {
 	// Transparent indicates that the color is unknown, so the next text must
 	// be preceded by an SGR (set graphics) ANSI sequence to set it.
 	Background color.RGBA
+	// Attributes are the text attributes for subsequent text, like bold or
+	// underline.
+	// The Start cursor's attributes are unknown, so the next text must be
+	// preceded by an SGR sequence that turns each attribute on or off.
+	Attributes Attr
 }
 
 var (
@@ -35,10 +41,12 @@ var (
 		Position:   Lost,
 		Foreground: Transparent,
 		Background: Transparent,
+		Attributes: unknownAttr,
 	}
 
-	// Reset is a cursor state indicating that the cursor is at the origin
-	// and that the foreground color is white (7), background black (0).
+	// Reset is a cursor state indicating that the cursor is at the origin,
+	// that the foreground color is white (7), background black (0), and that
+	// text is plain.
 	// This is the state cur.Reset() returns to, and the state for which
 	// cur.Reset() will append nothing to the buffer.
 	Reset = Cursor{
@@ -66,12 +74,13 @@ func (c Cursor) Clear(buf []byte) ([]byte, Cursor) {
 		Position:   Lost,
 		Foreground: c.Foreground,
 		Background: c.Background,
+		Attributes: c.Attributes,
 	}
 }
 
-// Reset returns the terminal to default white on black colors.
+// Reset returns the terminal to default white on black colors and plain text.
 func (c Cursor) Reset(buf []byte) ([]byte, Cursor) {
-	if c.Foreground == Colors[7] && c.Background == Colors[0] {
+	if c.Foreground == Colors[7] && c.Background == Colors[0] && c.Attributes == 0 {
 		return buf, c
 	}
 	return append(buf, "\033[m"...), Cursor{
@@ -81,6 +90,15 @@ func (c Cursor) Reset(buf []byte) ([]byte, Cursor) {
 	}
 }
 
+// SetAttr changes the text attributes for subsequent text, appending an SGR
+// sequence that turns on or off only the attributes that differ from the
+// cursor's current attributes.
+func (c Cursor) SetAttr(buf []byte, a Attr) ([]byte, Cursor) {
+	buf = renderAttr(buf, c.Attributes, a)
+	c.Attributes = a
+	return buf, c
+}
+
 // Home seeks the cursor to the origin, using display absolute coordinates.
 func (c Cursor) Home(buf []byte) ([]byte, Cursor) {
 	c.Position = image.ZP
@@ -96,11 +114,13 @@ func (c Cursor) Go(buf []byte, to image.Point) ([]byte, Cursor) {
 		// If the cursor position is completely unknown, move relative to
 		// screen origin. This mode must be avoided to render relative to
 		// cursor position inline with a scrolling log, by setting the cursor
-		// position relative to an arbitrary origin before rendering.
+		// position relative to an arbitrary origin before rendering, as
+		// RenderInline requires.
+		// The terminal numbers rows and columns from 1.
 		buf = append(buf, "\033["...)
-		buf = append(buf, strconv.Itoa(to.Y)...)
+		buf = append(buf, strconv.Itoa(to.Y+1)...)
 		buf = append(buf, ";"...)
-		buf = append(buf, strconv.Itoa(to.X)...)
+		buf = append(buf, strconv.Itoa(to.X+1)...)
 		buf = append(buf, "H"...)
 		c.Position = to
 		return buf, c
@@ -108,12 +128,10 @@ func (c Cursor) Go(buf []byte, to image.Point) ([]byte, Cursor) {
 
 	if c.Position.X == -1 {
 		// If only horizontal position is unknown, return to first column and
-		// march forward.
-		// Rendering a non-ASCII cell of unknown or indeterminite width may
-		// invalidate the column number.
-		// For example, a skin tone emoji may or may not render as a single
-		// column glyph.
-		buf = append(buf, "\r"...)
+		// march forward, as moving down does anyway.
+		if to.Y <= c.Position.Y {
+			buf = append(buf, "\r"...)
+		}
 		c.Position.X = 0
 		// Continue...
 	}
@@ -127,12 +145,6 @@ func (c Cursor) Go(buf []byte, to image.Point) ([]byte, Cursor) {
 		buf, c = c.Reset(buf)
 		buf = append(buf, "\r"...)
 		c.Position.X = 0
-
-		// In addition to scrolling back to the first column generally, this
-		// has the effect of resetting the column if writing a multi-byte
-		// string invalidates the cursor's horizontal position.
-		// For example, a skin tone emoji may or may not render as a single
-		// column glyph.
 	}
 
 	// DOWN
@@ -172,17 +184,13 @@ func (c Cursor) Go(buf []byte, to image.Point) ([]byte, Cursor) {
 // TODO: func (c Cursor) Write(buf, p []byte) ([]byte, Cursor)
 
 // WriteGlyph appends the given string's UTF8 bytes into the given
-// buffer, invalidating the cursor if the string COULD HAVE rendered
-// to more than one glyph; otherwise the cursor's X is advanced by 1.
+// buffer, advancing the cursor's X by the number of cells the string
+// occupies: two for East Asian wide glyphs and emoji, zero for combining
+// marks on their own, and one otherwise.
 func (c Cursor) WriteGlyph(buf []byte, s string) ([]byte, Cursor) {
 	buf = append(buf, s...)
-	if n := utf8.RuneCountInString(s); n == 1 {
-		c.Position.X++
-	} else {
-		// Invalidate cursor column to force position reset
-		// before next draw, if the string drawn might be longer
-		// than one cell wide or simply empty.
-		c.Position.X = -1
+	if c.Position.X >= 0 {
+		c.Position.X += textile.Width(s)
 	}
 	return buf, c
 }
@@ -0,0 +1,63 @@
+package display
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoFromLost(t *testing.T) {
+	buf, cur := Start.Go(nil, image.Pt(0, 0))
+	assert.Equal(t, "\033[1;1H", string(buf))
+	assert.Equal(t, image.Pt(0, 0), cur.Position)
+
+	buf, cur = Start.Go(nil, image.Pt(4, 2))
+	assert.Equal(t, "\033[3;5H", string(buf))
+	assert.Equal(t, image.Pt(4, 2), cur.Position)
+}
+
+func TestGoFromUnknownColumn(t *testing.T) {
+	cur := Reset
+	cur.Position = image.Pt(-1, 0)
+	buf, _ := cur.Go(nil, image.Pt(2, 0))
+	assert.Equal(t, "\r\033[2C", string(buf))
+
+	// Moving down returns to the first column once.
+	buf, _ = cur.Go(nil, image.Pt(2, 2))
+	assert.Equal(t, "\r\n\n\033[2C", string(buf))
+	buf, _ = cur.Go(nil, image.Pt(0, 1))
+	assert.Equal(t, "\r\n", string(buf))
+}
+
+func TestAlternateScreen(t *testing.T) {
+	// Switching screens moves the cursor to wherever it was on the other.
+	cur := Reset
+	buf, cur := cur.EnterAlternateScreen(nil)
+	assert.Equal(t, Lost, cur.Position)
+	cur.Position = image.ZP
+	buf, cur = cur.LeaveAlternateScreen(buf)
+	assert.Equal(t, Lost, cur.Position)
+	assert.Equal(t, "\033[?1049h\033[?1049l", string(buf))
+}
+
+func TestBracketedPasteAndFocus(t *testing.T) {
+	buf, cur := Reset.EnableBracketedPaste(nil)
+	buf, cur = cur.EnableFocusReporting(buf)
+	buf, cur = cur.DisableFocusReporting(buf)
+	buf, cur = cur.DisableBracketedPaste(buf)
+	assert.Equal(t, "\033[?2004h\033[?1004h\033[?1004l\033[?2004l", string(buf))
+	assert.Equal(t, Reset, cur)
+}
+
+func TestSetShape(t *testing.T) {
+	buf, _ := Start.SetShape(nil, CursorBar)
+	assert.Equal(t, "\033[6 q", string(buf))
+}
+
+func TestSynchronizedUpdate(t *testing.T) {
+	buf, cur := Start.BeginSynchronizedUpdate(nil)
+	buf, cur = cur.EndSynchronizedUpdate(buf)
+	assert.Equal(t, "\033[?2026h\033[?2026l", string(buf))
+	assert.Equal(t, Start, cur)
+}
@@ -1,7 +1,8 @@
 // Package display models, composes, and renders virtual terminal displays
 // using ANSI escape sequences.
-// Models displays as three layers: a text layer and foreground and background
-// color layers as images in any logical color space.
+// Models displays as four layers: a text layer, foreground and background
+// color layers as images in any logical color space, and a layer of text
+// attributes like bold and underline.
 // The package includes colors, palettes, and rendering models for terminal
 // displays supporting 0, 3, 4, 8, and 24 bit color.
 // The package also includes a cursor that tracks the known position and colors
@@ -25,6 +26,7 @@ func New(r image.Rectangle) *Display {
 		Background: image.NewRGBA(r),
 		Foreground: image.NewRGBA(r),
 		Text:       textile.New(r),
+		Attributes: NewAttributes(r),
 		Rect:       r,
 	}
 }
@@ -38,13 +40,29 @@ func New2(r image.Rectangle) (*Display, *Display) {
 	return New(r), New(r)
 }
 
-// Display models a terminal display's state as three images.
+// Resize returns new front and back displays for a terminal that has changed
+// size, appending the commands that clear the terminal.
+// Resize returns the Start cursor, so the next render makes no assumptions
+// about the position or colors of the cursor, and renders every cell of the
+// front display that is not blank.
+//
+//	case bounds := <-resizes:
+//		buf, cur, front, back = display.Resize(buf, cur, bounds)
+func Resize(buf []byte, cur Cursor, r image.Rectangle) ([]byte, Cursor, *Display, *Display) {
+	buf, cur = cur.Reset(buf)
+	buf, cur = cur.Clear(buf)
+	front, back := New2(r)
+	return buf, Start, front, back
+}
+
+// Display models a terminal display's state as three images and a layer of
+// text attributes.
 type Display struct {
 	Background *image.RGBA
 	Foreground *image.RGBA
 	Text       *textile.Textile
+	Attributes *Attributes
 	Rect       image.Rectangle
-	// TODO underline and intensity
 }
 
 // SubDisplay returns a mutable sub-region within the display, sharing the same
@@ -55,12 +73,13 @@ func (d *Display) SubDisplay(r image.Rectangle) *Display {
 		Background: d.Background.SubImage(r).(*image.RGBA),
 		Foreground: d.Foreground.SubImage(r).(*image.RGBA),
 		Text:       d.Text.SubText(r),
+		Attributes: d.Attributes.SubAttributes(r),
 		Rect:       r,
 	}
 }
 
 // Fill overwrites every cell with the given text and foreground and background
-// colors.
+// colors, with plain text attributes.
 func (d *Display) Fill(r image.Rectangle, t string, f, b color.Color) {
 	r = r.Intersect(d.Rect)
 	for y := r.Min.Y; y < r.Max.Y; y++ {
@@ -76,11 +95,17 @@ func (d *Display) Clear(r image.Rectangle) {
 }
 
 // Set overwrites the text and foreground and background colors of the cell at
-// the given position.
+// the given position, with plain text attributes.
 func (d *Display) Set(x, y int, t string, f, b color.Color) {
 	d.Text.Set(x, y, t)
 	d.Foreground.Set(x, y, rgba(f))
 	d.Background.Set(x, y, rgba(b))
+	d.Attributes.Set(x, y, 0)
+}
+
+// SetAttr overwrites the text attributes of the cell at the given position.
+func (d *Display) SetAttr(x, y int, a Attr) {
+	d.Attributes.Set(x, y, a)
 }
 
 // Draw composes one display over another. The bounds dictate the region of the
@@ -89,6 +114,9 @@ func (d *Display) Set(x, y int, t string, f, b color.Color) {
 // Overwrite the text layer for all non-empty text cells inside the rectangle.
 // Fill the text with space " " to overdraw all cells.
 //
+// Overwrite the attributes layer for the same cells, so attributes travel
+// with the text they decorate.
+//
 // Draw the foreground of the source over the foreground of the destination
 // image.  Typically, the foreground is transparent for all cells empty of
 // text.  Otherwise, this operation can have interesting results.
@@ -107,9 +135,23 @@ func Draw(dst *Display, r image.Rectangle, src *Display, sp image.Point, op draw
 	draw.Draw(dst.Background, r, src.Background, sp, op)
 	draw.Draw(dst.Foreground, r, src.Background, sp, op)
 	draw.Draw(dst.Foreground, r, src.Foreground, sp, op)
+	drawAttributes(dst, r, src, sp)
 	textile.Draw(dst.Text, r, src.Text, sp)
 }
 
+// drawAttributes copies the attributes of every cell of the source that has
+// non-empty text onto the destination.
+func drawAttributes(dst *Display, r image.Rectangle, src *Display, sp image.Point) {
+	w, h := r.Dx(), r.Dy()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if src.Text.At(sp.X+x, sp.Y+y) != "" {
+				dst.Attributes.Set(r.Min.X+x, r.Min.Y+y, src.Attributes.At(sp.X+x, sp.Y+y))
+			}
+		}
+	}
+}
+
 // At returns the text and foreground and background colors at the given
 // coordinates.
 func (d *Display) At(x, y int) (t string, f, b color.Color) {
@@ -119,6 +161,14 @@ func (d *Display) At(x, y int) (t string, f, b color.Color) {
 	return d.Text.At(x, y), rgba(d.Foreground.At(x, y)), rgba(d.Background.At(x, y))
 }
 
+// AttrAt returns the text attributes at the given coordinates.
+func (d *Display) AttrAt(x, y int) Attr {
+	if d == nil {
+		return 0
+	}
+	return d.Attributes.At(x, y)
+}
+
 // Bounds returns the bounding rectangle of the display.
 func (d *Display) Bounds() image.Rectangle {
 	return d.Rect
@@ -134,24 +184,105 @@ func Render(buf []byte, cur Cursor, over *Display, model Model) ([]byte, Cursor)
 // terminal display to look like the front model, skipping cells that are the
 // same in the back model, using escape sequences and the nearest matching
 // colors in the given color model.
+//
+// A wide glyph renders over its own cell and the continuation cell to its
+// right, so RenderOver skips continuation cells, and renders a blank in place
+// of a wide glyph or continuation that has lost its partner.
+//
+// RenderOver weighs the cost of the ways to reach each changed cell, moving
+// relative to the cursor, to an absolute position, or rewriting the unchanged
+// cells in between.
+// RenderCompressed also compresses runs of cells, for terminals that support
+// the sequences.
 func RenderOver(buf []byte, cur Cursor, over, under *Display, model Model) ([]byte, Cursor) {
+	return renderOver(buf, cur, over, under, model, false, 0)
+}
+
+// RenderCompressed is like RenderOver, but may also render runs of cells with
+// the escape sequences the caller selects, where those are shorter.
+// Not every terminal supports them, and terminals do not report whether they
+// do, so the caller must know its terminal.
+//
+//	buf, cur = display.RenderCompressed(buf, cur, front, back, display.Model24,
+//		display.CompressErase|display.CompressRepeat)
+func RenderCompressed(buf []byte, cur Cursor, over, under *Display, model Model, compress Compress) ([]byte, Cursor) {
+	return renderOver(buf, cur, over, under, model, false, compress)
+}
+
+// RenderInline is like RenderOver, but moves the cursor only relative to its
+// current position, never to an absolute position, so the display may render
+// anywhere on the terminal, like inline below a shell prompt, where the
+// terminal has scrolled an unknown number of rows.
+// The cursor's position must be known, relative to the display's origin.
+func RenderInline(buf []byte, cur Cursor, over, under *Display, model Model) ([]byte, Cursor) {
+	return renderOver(buf, cur, over, under, model, true, 0)
+}
+
+func renderOver(buf []byte, cur Cursor, over, under *Display, model Model, relative bool, compress Compress) ([]byte, Cursor) {
 	for y := over.Rect.Min.Y; y < over.Rect.Max.Y; y++ {
 		for x := over.Rect.Min.X; x < over.Rect.Max.X; x++ {
-			ot, of, ob := over.At(x, y)
-			ut, uf, ub := under.At(x, y)
-			if len(ot) == 0 {
-				ot = " "
-			}
-			if len(ut) == 0 {
-				ut = " "
+			ot, covered := over.GlyphAt(x, y)
+			if covered {
+				continue
 			}
-			if ot == ut && of == uf && ob == ub {
+			if sameCell(over, under, x, y) {
 				continue
 			}
-			buf, cur = cur.Go(buf, image.Pt(x, y))
+			_, of, ob := over.At(x, y)
+			oa := over.AttrAt(x, y)
+			buf, cur = cur.seek(buf, image.Pt(x, y), over, relative)
+			buf, cur = cur.SetAttr(buf, oa)
 			buf, cur = model.Render(buf, cur, of, ob)
+
+			if n := over.blankRun(x, y); n > 1 && compress&(CompressErase|CompressEraseLine) != 0 {
+				// Erase through the last blank that changes, or to the end
+				// of the row.
+				m := lastChange(over, under, x, y, n)
+				if compress&CompressEraseLine != 0 && x+n == over.Rect.Max.X && len("\033[K") < m {
+					buf, cur = cur.EraseLine(buf)
+					x += n - 1
+					continue
+				} else if compress&CompressErase != 0 && len("\033[X")+digits(m) < m {
+					buf, cur = cur.EraseCharacters(buf, m)
+					x += m - 1
+					continue
+				}
+			}
+
 			buf, cur = cur.WriteGlyph(buf, ot)
+			if n := over.repeatRun(x, y); n > 0 && compress&CompressRepeat != 0 {
+				if m := lastChange(over, under, x+1, y, n); len("\033[b")+digits(m) < m {
+					buf, cur = cur.Repeat(buf, m)
+					x += m
+				}
+			}
 		}
 	}
 	return buf, cur
 }
+
+// GlyphAt returns the text to render for a cell, substituting a space for
+// an empty cell and for a wide glyph or continuation without its partner.
+// Returns an empty string and true if the cell is covered by the wide glyph
+// to its left.
+func (d *Display) GlyphAt(x, y int) (string, bool) {
+	if d == nil {
+		return " ", false
+	}
+	t := d.Text.At(x, y)
+	switch {
+	case t == "":
+		return " ", false
+	case t == textile.Continuation:
+		if x > d.Rect.Min.X && textile.Width(d.Text.At(x-1, y)) == 2 {
+			return "", true
+		}
+		return " ", false
+	case textile.Width(t) == 2:
+		if x+1 < d.Rect.Max.X && d.Text.At(x+1, y) == textile.Continuation {
+			return t, false
+		}
+		return " ", false
+	}
+	return t, false
+}
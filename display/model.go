@@ -18,15 +18,28 @@ type model struct {
 }
 
 func (m model) Render(buf []byte, cur Cursor, fg, bg color.Color) ([]byte, Cursor) {
-	if fg != cur.Foreground {
-		buf = m.foreground(buf, fg)
-		cur.Foreground = rgba(fg)
+	buf, cur.Foreground = renderChange(buf, m.foreground, cur.Foreground, fg)
+	buf, cur.Background = renderChange(buf, m.background, cur.Background, bg)
+	return buf, cur
+}
+
+// renderChange appends the sequence for a color, unless the cursor's color is
+// known and renders the same, returning the cursor's new color.
+// The terminal has no notion of transparency, and a transparent cursor color
+// means the color is unknown, so the cursor's new color is opaque.
+func renderChange(buf []byte, render func([]byte, color.Color) []byte, from color.RGBA, to color.Color) ([]byte, color.RGBA) {
+	c := rgba(to)
+	c.A = 255
+	if from == c {
+		return buf, from
 	}
-	if bg != cur.Background {
-		buf = m.background(buf, bg)
-		cur.Background = rgba(bg)
+	if from != Transparent {
+		var a, b [32]byte
+		if string(render(a[:0], from)) == string(render(b[:0], c)) {
+			return buf, from
+		}
 	}
-	return buf, cur
+	return render(buf, c), c
 }
 
 var (
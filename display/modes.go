@@ -0,0 +1,91 @@
+package display
+
+import "strconv"
+
+// EnterAlternateScreen switches to the alternate screen buffer, saving the
+// cursor and the contents of the normal screen, so that leaving the alternate
+// screen restores the user's scrollback as it was.
+// The alternate screen begins blank.
+func (c Cursor) EnterAlternateScreen(buf []byte) ([]byte, Cursor) {
+	c.Position = Lost
+	return append(buf, "\033[?1049h"...), c
+}
+
+// LeaveAlternateScreen returns to the normal screen buffer, restoring its
+// contents and the cursor position saved when entering the alternate screen.
+func (c Cursor) LeaveAlternateScreen(buf []byte) ([]byte, Cursor) {
+	c.Position = Lost
+	return append(buf, "\033[?1049l"...), c
+}
+
+// EnableBracketedPaste asks the terminal to bracket pasted text with escape
+// sequences, so that it can be distinguished from typed keys.
+// The "input" package decodes bracketed paste as a single event.
+func (c Cursor) EnableBracketedPaste(buf []byte) ([]byte, Cursor) {
+	return append(buf, "\033[?2004h"...), c
+}
+
+// DisableBracketedPaste stops bracketing pasted text.
+func (c Cursor) DisableBracketedPaste(buf []byte) ([]byte, Cursor) {
+	return append(buf, "\033[?2004l"...), c
+}
+
+// EnableFocusReporting asks the terminal to report when its window gains or
+// loses focus.
+// The "input" package decodes focus reports as events.
+func (c Cursor) EnableFocusReporting(buf []byte) ([]byte, Cursor) {
+	return append(buf, "\033[?1004h"...), c
+}
+
+// DisableFocusReporting stops reporting focus changes.
+func (c Cursor) DisableFocusReporting(buf []byte) ([]byte, Cursor) {
+	return append(buf, "\033[?1004l"...), c
+}
+
+// CursorShape is the shape of the text cursor, as set by the DECSCUSR escape
+// sequence.
+type CursorShape int
+
+const (
+	// CursorDefault is the cursor shape the user configured for the
+	// terminal.
+	CursorDefault CursorShape = iota
+	// CursorBlinkingBlock is a blinking block over the cell.
+	CursorBlinkingBlock
+	// CursorBlock is a steady block over the cell.
+	CursorBlock
+	// CursorBlinkingUnderline is a blinking line under the cell.
+	CursorBlinkingUnderline
+	// CursorUnderline is a steady line under the cell.
+	CursorUnderline
+	// CursorBlinkingBar is a blinking bar at the left edge of the cell.
+	CursorBlinkingBar
+	// CursorBar is a steady bar at the left edge of the cell.
+	CursorBar
+)
+
+// SetShape changes the shape of the text cursor.
+func (c Cursor) SetShape(buf []byte, s CursorShape) ([]byte, Cursor) {
+	buf = append(buf, "\033["...)
+	buf = append(buf, strconv.Itoa(int(s))...)
+	return append(buf, " q"...), c
+}
+
+// BeginSynchronizedUpdate asks the terminal to hold its screen still until
+// EndSynchronizedUpdate, so that a large frame appears all at once instead of
+// tearing.
+// Terminals that do not support synchronized output ignore the request, but
+// some old terminals print it, so use terminal.QueryMode to see whether the
+// terminal supports terminal.SynchronizedOutput first.
+//
+//	buf, cur = cur.BeginSynchronizedUpdate(buf)
+//	buf, cur = display.RenderOver(buf, cur, front, back, display.Model24)
+//	buf, cur = cur.EndSynchronizedUpdate(buf)
+func (c Cursor) BeginSynchronizedUpdate(buf []byte) ([]byte, Cursor) {
+	return append(buf, "\033[?2026h"...), c
+}
+
+// EndSynchronizedUpdate shows the frame since BeginSynchronizedUpdate.
+func (c Cursor) EndSynchronizedUpdate(buf []byte) ([]byte, Cursor) {
+	return append(buf, "\033[?2026l"...), c
+}
@@ -0,0 +1,37 @@
+package display
+
+import (
+	"strconv"
+)
+
+// MouseTracking selects which mouse events a terminal reports.
+type MouseTracking int
+
+const (
+	// MouseButtons reports presses and releases of mouse buttons, including
+	// the scroll wheel.
+	MouseButtons MouseTracking = 1000
+	// MouseDrag also reports motion while a button is held.
+	MouseDrag MouseTracking = 1002
+	// MouseMotion reports all motion, with or without a button held.
+	MouseMotion MouseTracking = 1003
+)
+
+// EnableMouse asks the terminal to report mouse events with the given
+// tracking mode, using the SGR (1006) encoding so that coordinates are not
+// limited to 223 columns or rows.
+// Terminals that do not support the SGR encoding fall back to the X10
+// encoding.
+// The "input" package decodes both encodings.
+func (c Cursor) EnableMouse(buf []byte, t MouseTracking) ([]byte, Cursor) {
+	buf = append(buf, "\033[?"...)
+	buf = append(buf, strconv.Itoa(int(t))...)
+	buf = append(buf, "h\033[?1006h"...)
+	return buf, c
+}
+
+// DisableMouse asks the terminal to stop reporting mouse events, for every
+// tracking mode.
+func (c Cursor) DisableMouse(buf []byte) ([]byte, Cursor) {
+	return append(buf, "\033[?1006l\033[?1003l\033[?1002l\033[?1000l"...), c
+}
@@ -0,0 +1,210 @@
+package display
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+)
+
+// Compress selects the escape sequences RenderCompressed may use to render
+// runs of cells in fewer bytes.
+type Compress int
+
+const (
+	// CompressErase erases runs of blank cells with ECH.
+	CompressErase Compress = 1 << iota
+	// CompressRepeat repeats runs of the same character with REP, which some
+	// terminals, like the Linux console, do not support.
+	CompressRepeat
+	// CompressEraseLine erases runs of blank cells that reach the right edge
+	// of the display with EL, which erases to the right edge of the terminal,
+	// so only for a display that reaches the right edge of the terminal.
+	CompressEraseLine
+)
+
+// seek moves the cursor to another position on the same display, like Go,
+// but with the cheapest of Go's relative motion, an absolute position, or,
+// moving right along a row, rewriting the cells in between, which must be
+// unchanged and in the cursor's current colors and attributes.
+// A relative seek never moves to an absolute position.
+func (c Cursor) seek(buf []byte, to image.Point, d *Display, relative bool) ([]byte, Cursor) {
+	if c.Position == to {
+		return buf, c
+	}
+	if c.Position.X >= d.Rect.Max.X {
+		// Having written the last column, the terminal may hold the cursor
+		// there until the next glyph, so only the row is certain.
+		c.Position.X = -1
+	}
+	if c.Position.X < 0 || c.Position.Y < 0 {
+		return c.Go(buf, to)
+	}
+
+	start := len(buf)
+	buf, best := c.Go(buf, to)
+	cost := len(buf) - start
+
+	if n := cupCost(to); !relative && n < cost {
+		buf = appendCUP(buf[:start], to)
+		best, cost = c, n
+		best.Position = to
+	}
+
+	if n, ok := c.gapCost(d, to); ok && n < cost {
+		buf = buf[:start]
+		for x := c.Position.X; x < to.X; x++ {
+			t, covered := d.GlyphAt(x, to.Y)
+			if !covered {
+				buf = append(buf, t...)
+			}
+		}
+		best = c
+		best.Position = to
+	}
+
+	return buf, best
+}
+
+// cupCost returns the length of the CUP sequence to an absolute position.
+func cupCost(to image.Point) int {
+	return len("\033[;H") + digits(to.Y+1) + digits(to.X+1)
+}
+
+func appendCUP(buf []byte, to image.Point) []byte {
+	buf = append(buf, "\033["...)
+	buf = append(buf, strconv.Itoa(to.Y+1)...)
+	buf = append(buf, ";"...)
+	buf = append(buf, strconv.Itoa(to.X+1)...)
+	return append(buf, "H"...)
+}
+
+// gapCost returns the number of bytes it takes to rewrite the cells from the
+// cursor to a position to its right on the same row, and whether rewriting
+// them is possible without changing colors or attributes.
+func (c Cursor) gapCost(d *Display, to image.Point) (int, bool) {
+	if to.Y != c.Position.Y || to.X <= c.Position.X {
+		return 0, false
+	}
+	if c.Foreground == Transparent || c.Background == Transparent || c.Attributes&unknownAttr != 0 {
+		return 0, false
+	}
+	n := 0
+	for x := c.Position.X; x < to.X; x++ {
+		t, covered := d.GlyphAt(x, to.Y)
+		if covered {
+			continue
+		}
+		// The cursor's colors are opaque, as the model rendered them.
+		f, b, a := d.style(x, to.Y)
+		f.A, b.A = 255, 255
+		// A blank shows its foreground only if reversed.
+		if b != c.Background || a != c.Attributes || ((t != " " || a&Reverse != 0) && f != c.Foreground) {
+			return 0, false
+		}
+		n += len(t)
+	}
+	return n, true
+}
+
+// visibleOnBlank are the attributes that show even on a blank cell, which
+// erasing does not reproduce.
+const visibleOnBlank = Underline | Reverse | Strikethrough
+
+// blankRun returns the number of cells, from the given one, that render as
+// blanks with the same background color and attributes that do not show on
+// blanks, such that erasing them is the same as writing spaces.
+func (d *Display) blankRun(x, y int) int {
+	_, bg, a := d.style(x, y)
+	if a&visibleOnBlank != 0 {
+		return 0
+	}
+	n := 0
+	for ; x+n < d.Rect.Max.X; n++ {
+		t, covered := d.GlyphAt(x+n, y)
+		_, b, a := d.style(x+n, y)
+		if covered || t != " " || b != bg || a&visibleOnBlank != 0 {
+			break
+		}
+	}
+	return n
+}
+
+// repeatRun returns the number of cells following the given one that render
+// the same printable ASCII character in the same colors and attributes, which
+// the REP sequence can repeat.
+func (d *Display) repeatRun(x, y int) int {
+	t, _ := d.GlyphAt(x, y)
+	if len(t) != 1 || t[0] < ' ' || t[0] > '~' {
+		return 0
+	}
+	f, b, a := d.style(x, y)
+	n := 0
+	for x := x + 1; x < d.Rect.Max.X; x++ {
+		u, _ := d.GlyphAt(x, y)
+		g, c, e := d.style(x, y)
+		if u != t || g != f || c != b || e != a {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// lastChange returns the number of cells from the first to the last cell of
+// a run that differ between the displays, or 0 if none differ.
+func lastChange(over, under *Display, x, y, n int) int {
+	for i := n; i > 0; i-- {
+		if !sameCell(over, under, x+i-1, y) {
+			return i
+		}
+	}
+	return 0
+}
+
+// sameCell returns whether a cell renders the same on both displays.
+func sameCell(over, under *Display, x, y int) bool {
+	ot, _ := over.GlyphAt(x, y)
+	ut, _ := under.GlyphAt(x, y)
+	of, ob, oa := over.style(x, y)
+	uf, ub, ua := under.style(x, y)
+	return ot == ut && of == uf && ob == ub && oa == ua
+}
+
+// style returns the colors and attributes of a cell, like At, but without
+// allocating.
+func (d *Display) style(x, y int) (f, b color.RGBA, a Attr) {
+	if d == nil {
+		return Colors[7], Transparent, 0
+	}
+	return d.Foreground.RGBAAt(x, y), d.Background.RGBAAt(x, y), d.Attributes.At(x, y)
+}
+
+// EraseLine erases from the cursor to the end of the line, with the current
+// background color, without moving the cursor.
+func (c Cursor) EraseLine(buf []byte) ([]byte, Cursor) {
+	return append(buf, "\033[K"...), c
+}
+
+// EraseCharacters erases a number of cells from the cursor, with the current
+// background color, without moving the cursor.
+func (c Cursor) EraseCharacters(buf []byte, n int) ([]byte, Cursor) {
+	buf = append(buf, "\033["...)
+	buf = append(buf, strconv.Itoa(n)...)
+	return append(buf, "X"...), c
+}
+
+// Repeat repeats the character the cursor last wrote a number of times,
+// advancing the cursor.
+func (c Cursor) Repeat(buf []byte, n int) ([]byte, Cursor) {
+	buf = append(buf, "\033["...)
+	buf = append(buf, strconv.Itoa(n)...)
+	buf = append(buf, "b"...)
+	if c.Position.X >= 0 {
+		c.Position.X += n
+	}
+	return buf, c
+}
+
+func digits(n int) int {
+	return len(strconv.Itoa(n))
+}
@@ -0,0 +1,124 @@
+package display
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeekAbsolute(t *testing.T) {
+	d := New(image.Rect(0, 0, 80, 24))
+	buf, cur := Reset.seek(nil, image.Pt(70, 20), d, false)
+	assert.Equal(t, "\033[21;71H", string(buf))
+	assert.Equal(t, image.Pt(70, 20), cur.Position)
+
+	buf, _ = Reset.seek(nil, image.Pt(2, 1), d, false)
+	assert.Equal(t, "\r\n\033[2C", string(buf))
+}
+
+func TestSeekRelative(t *testing.T) {
+	d := New(image.Rect(0, 0, 80, 24))
+	buf, cur := Reset.seek(nil, image.Pt(70, 20), d, true)
+	assert.Equal(t, "\r\n\n\n\n\n\n\n\n\n\n\n\n\n\n\n\n\n\n\n\n\033[70C", string(buf))
+	assert.Equal(t, image.Pt(70, 20), cur.Position)
+
+	// Past the last column, the terminal's cursor may be on it or beyond it.
+	cur.Position = image.Pt(80, 0)
+	buf, _ = cur.seek(nil, image.Pt(78, 0), d, true)
+	assert.Equal(t, "\r\033[78C", string(buf))
+}
+
+func TestRenderInline(t *testing.T) {
+	front := New(image.Rect(0, 0, 80, 24))
+	front.Set(70, 20, "x", Colors[7], Colors[0])
+	buf, _ := RenderInline(nil, Reset, front, New(front.Rect), Model0)
+	assert.NotContains(t, string(buf), "H")
+	assert.Equal(t, "\r\n\n\n\n\n\n\n\n\n\n\n\n\n\n\n\n\n\n\n\n\033[70Cx", string(buf))
+}
+
+func TestRenderAfterLastColumn(t *testing.T) {
+	// Writing the last column leaves the terminal's cursor on it, with a wrap
+	// pending, so the next motion on the row must not count from beyond it.
+	front, back := lines(10, "         x"), lines(10, "          ")
+	buf, cur := RenderOver(nil, Reset, front, back, Model0)
+	assert.Equal(t, "\033[9Cx", string(buf))
+
+	front, back = lines(10, " y       x"), front
+	buf, _ = RenderOver(nil, cur, front, back, Model0)
+	assert.Equal(t, "\r\033[1Cy", string(buf))
+}
+
+func TestSeekRewritesGap(t *testing.T) {
+	d := lines(8, "abcdefgh")
+	buf, cur := Reset.seek(nil, image.Pt(3, 0), d, false)
+	assert.Equal(t, "abc", string(buf))
+	assert.Equal(t, image.Pt(3, 0), cur.Position)
+
+	// Cells in other colors cannot be rewritten without changing colors.
+	d.Set(1, 0, "b", Colors[1], Colors[0])
+	buf, _ = Reset.seek(nil, image.Pt(3, 0), d, false)
+	assert.Equal(t, "\033[3C", string(buf))
+
+	// Nor can a reversed blank, which shows its foreground.
+	d = New(image.Rect(0, 0, 8, 1))
+	d.Set(0, 0, " ", Colors[7], Colors[0])
+	d.SetAttr(0, 0, Reverse)
+	d.Set(1, 0, " ", Colors[1], Colors[0])
+	d.SetAttr(1, 0, Reverse)
+	cur = Reset
+	cur.Foreground, cur.Background, cur.Attributes = Colors[7], Colors[0], Reverse
+	buf, _ = cur.seek(nil, image.Pt(1, 0), d, false)
+	assert.Equal(t, " ", string(buf))
+	buf, _ = cur.seek(nil, image.Pt(2, 0), d, false)
+	assert.Equal(t, "\033[2C", string(buf))
+}
+
+func TestRenderEraseLine(t *testing.T) {
+	front, back := lines(10, "ab        "), lines(10, "xxxxxxxxxx")
+	buf, _ := RenderCompressed(nil, Reset, front, back, Model0, CompressEraseLine)
+	assert.Equal(t, "ab\033[K", string(buf))
+
+	// Without EL, ECH erases as far as the edge of the display.
+	buf, _ = RenderCompressed(nil, Reset, front, back, Model0, CompressErase)
+	assert.Equal(t, "ab\033[8X", string(buf))
+}
+
+func TestRenderEraseCharacters(t *testing.T) {
+	front, back := lines(10, "a        b"), lines(10, "xxxxxxxxxx")
+	buf, _ := RenderCompressed(nil, Reset, front, back, Model0, CompressErase|CompressEraseLine)
+	assert.Equal(t, "a\033[8X\033[8Cb", string(buf))
+}
+
+func TestRenderRepeat(t *testing.T) {
+	front, back := lines(10, "=========="), lines(10, "")
+	buf, cur := RenderCompressed(nil, Reset, front, back, Model0, CompressRepeat)
+	assert.Equal(t, "=\033[9b", string(buf))
+	assert.Equal(t, image.Pt(10, 0), cur.Position)
+
+	// Only as far as the last change.
+	front, back = lines(20, "===================="), lines(20, "          ==========")
+	buf, cur = RenderCompressed(nil, Reset, front, back, Model0, CompressRepeat)
+	assert.Equal(t, "=\033[9b", string(buf))
+	assert.Equal(t, image.Pt(10, 0), cur.Position)
+}
+
+func TestRenderUncompressed(t *testing.T) {
+	front, back := lines(10, "===       "), lines(10, "xxxxxxxxxx")
+	buf, _ := RenderOver(nil, Reset, front, back, Model0)
+	assert.Equal(t, "===       ", string(buf))
+}
+
+func TestRenderEquivalentColors(t *testing.T) {
+	// Transparent renders as black, so the terminal needs no change between
+	// them.
+	d := New(image.Rect(0, 0, 3, 1))
+	d.Set(0, 0, "a", Colors[7], Transparent)
+	d.Set(1, 0, "b", Colors[7], Colors[0])
+	d.Set(2, 0, "c", Colors[7], Transparent)
+	cur := Reset
+	cur.Background = Colors[4]
+	buf, cur := Render(nil, cur, d, Model24)
+	assert.Equal(t, "\033[48;5;16mabc", string(buf))
+	assert.Equal(t, Colors[0], cur.Background)
+}
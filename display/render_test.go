@@ -5,40 +5,96 @@ import (
 	"image/color"
 	"testing"
 
+	"github.com/kriskowal/cops/textile"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestRenderMultiRuneCell(t *testing.T) {
 	whiteHand := "👍🏻"
-	d := New(image.Rect(0, 0, 2, 1))
+	d := New(image.Rect(0, 0, 4, 1))
 	d.Set(0, 0, whiteHand, color.White, color.Transparent)
-	d.Set(1, 0, whiteHand, color.White, color.Transparent)
+	d.Set(1, 0, textile.Continuation, color.White, color.Transparent)
+	d.Set(2, 0, whiteHand, color.White, color.Transparent)
+	d.Set(3, 0, textile.Continuation, color.White, color.Transparent)
 	cur := Reset
 	var buf []byte
 	buf, cur = Render(buf, cur, d, Model0)
-	assert.Equal(t, []byte(whiteHand+"\r\033[1C"+whiteHand), buf)
+	assert.Equal(t, []byte(whiteHand+whiteHand), buf)
+	assert.Equal(t, image.Pt(4, 0), cur.Position)
 }
 
 func TestRenderBlankAndMultiRuneCell(t *testing.T) {
 	whiteHand := "👍🏻"
-	d := New(image.Rect(0, 0, 3, 1))
+	d := New(image.Rect(0, 0, 4, 1))
 	d.Set(0, 0, "", color.White, color.Transparent)
 	d.Set(1, 0, whiteHand, color.White, color.Transparent)
-	d.Set(2, 0, "", color.White, color.Transparent)
+	d.Set(2, 0, textile.Continuation, color.White, color.Transparent)
+	d.Set(3, 0, "", color.White, color.Transparent)
 	cur := Reset
 	var buf []byte
 	buf, cur = Render(buf, cur, d, Model0)
-	assert.Equal(t, []byte(" "+whiteHand+"\r\033[2C "), buf)
+	assert.Equal(t, []byte(" "+whiteHand+" "), buf)
 }
 
 func TestRenderBlankAndMultiRuneCellOver(t *testing.T) {
 	whiteHand := "👍🏻"
-	front, back := New2(image.Rect(0, 0, 3, 1))
+	front, back := New2(image.Rect(0, 0, 4, 1))
 	front.Set(0, 0, "", color.White, color.Transparent)
 	front.Set(1, 0, whiteHand, color.White, color.Transparent)
-	front.Set(2, 0, "", color.White, color.Transparent)
+	front.Set(2, 0, textile.Continuation, color.White, color.Transparent)
+	front.Set(3, 0, "", color.White, color.Transparent)
+	cur := Reset
+	var buf []byte
+	buf, cur = RenderOver(buf, cur, front, back, Model0)
+	assert.Equal(t, []byte(" "+whiteHand+" "), buf)
+}
+
+func TestRenderCombiningAndWideCells(t *testing.T) {
+	d := New(image.Rect(0, 0, 4, 1))
+	d.Set(0, 0, "e\u0301", color.White, color.Transparent)
+	d.Set(1, 0, "日", color.White, color.Transparent)
+	d.Set(2, 0, textile.Continuation, color.White, color.Transparent)
+	d.Set(3, 0, "x", color.White, color.Transparent)
+	cur := Reset
+	var buf []byte
+	buf, cur = Render(buf, cur, d, Model0)
+	assert.Equal(t, "e\u0301日x", string(buf))
+}
+
+func TestRenderWideCellWithoutContinuation(t *testing.T) {
+	front, back := New2(image.Rect(0, 0, 3, 1))
+	back.Set(0, 0, "日", color.White, color.Transparent)
+	back.Set(1, 0, textile.Continuation, color.White, color.Transparent)
+	front.Set(0, 0, "日", color.White, color.Transparent)
+	front.Set(1, 0, "a", color.White, color.Transparent)
 	cur := Reset
 	var buf []byte
 	buf, cur = RenderOver(buf, cur, front, back, Model0)
-	assert.Equal(t, []byte(" "+whiteHand+"\r\033[2C "), buf)
+	assert.Equal(t, " a", string(buf))
+}
+
+func TestRenderAttributes(t *testing.T) {
+	d := New(image.Rect(0, 0, 3, 1))
+	d.Set(0, 0, "a", Colors[7], Colors[0])
+	d.SetAttr(0, 0, Bold|Underline)
+	d.Set(1, 0, "b", Colors[7], Colors[0])
+	d.SetAttr(1, 0, Underline)
+	d.Set(2, 0, "c", Colors[7], Colors[0])
+	cur := Reset
+	var buf []byte
+	buf, cur = Render(buf, cur, d, Model0)
+	assert.Equal(t, "\033[1;4ma\033[22mb\033[24mc", string(buf))
+}
+
+func TestRenderBoldAndDim(t *testing.T) {
+	d := New(image.Rect(0, 0, 4, 1))
+	d.Set(0, 0, "a", Colors[7], Colors[0])
+	d.SetAttr(0, 0, Bold)
+	d.Set(1, 0, "b", Colors[7], Colors[0])
+	d.SetAttr(1, 0, Bold|Dim)
+	d.Set(2, 0, "c", Colors[7], Colors[0])
+	d.SetAttr(2, 0, Dim)
+	d.Set(3, 0, "d", Colors[7], Colors[0])
+	buf, _ := Render(nil, Reset, d, Model0)
+	assert.Equal(t, "\033[1ma\033[2mb\033[22;2mc\033[22md", string(buf))
 }
@@ -0,0 +1,180 @@
+package display
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"image"
+	"strconv"
+)
+
+// scrollCost is roughly the number of bytes it takes to scroll a region of
+// the terminal, which a scroll must save to be worthwhile.
+const scrollCost = 16
+
+// RenderScrolling is like RenderOver, but first looks for a band of rows that
+// moved up or down between the back model and the front model, as when a log
+// scrolls, and, if moving them in the terminal costs less than repainting
+// them, scrolls them into place within a scrolling region, leaving only the
+// rows that scrolled into view and any other changes to repaint.
+//
+// The terminal scrolls whole rows, so the front and back models must span the
+// full width of the terminal, as for a full-screen application.
+// RenderScrolling scrolls at most one band of rows per frame.
+func RenderScrolling(buf []byte, cur Cursor, over, under *Display, model Model) ([]byte, Cursor) {
+	if under != nil && under.Rect == over.Rect {
+		if s, ok := findScroll(over, under); ok {
+			buf, cur = s.render(buf, cur)
+			under = s.apply(under)
+		}
+	}
+	return RenderOver(buf, cur, over, under, model)
+}
+
+// scroll is a region of rows, from top inclusive to bottom exclusive, whose
+// content moves up by dy rows, or down for negative dy.
+type scroll struct {
+	top, bottom, dy int
+}
+
+// findScroll finds the scroll that saves the most rows of repainting, if any
+// saves enough to be worth the cost of scrolling.
+func findScroll(over, under *Display) (scroll, bool) {
+	r := over.Rect
+	h := r.Dy()
+	overHash := make([]uint64, h)
+	underHash := make([]uint64, h)
+	changed := make([]int, h+1)
+	for j := 0; j < h; j++ {
+		overHash[j] = hashRow(over, r.Min.Y+j)
+		underHash[j] = hashRow(under, r.Min.Y+j)
+		// changed counts the rows above each row that differ in place.
+		changed[j+1] = changed[j]
+		if overHash[j] != underHash[j] || !sameRow(over, r.Min.Y+j, under, r.Min.Y+j) {
+			changed[j+1]++
+		}
+	}
+
+	var best scroll
+	bestSaved := 0
+	for dy := 1 - h; dy < h; dy++ {
+		if dy == 0 {
+			continue
+		}
+		// Find each maximal run of rows, from a to b, of the front model that
+		// match the rows dy below them in the back model.
+		for a := 0; a < h; {
+			b := a
+			for b < h && b+dy >= 0 && b+dy < h &&
+				overHash[b] == underHash[b+dy] && sameRow(over, r.Min.Y+b, under, r.Min.Y+b+dy) {
+				b++
+			}
+			if b == a {
+				a++
+				continue
+			}
+			s := scroll{top: a, bottom: b + dy, dy: dy}
+			if dy < 0 {
+				s = scroll{top: a + dy, bottom: b, dy: dy}
+			}
+			// Scrolling saves repainting the rows of the region that change
+			// in place, but the rows that scroll into view need repainting.
+			saved := changed[s.bottom] - changed[s.top] - abs(dy)
+			if saved > bestSaved {
+				best, bestSaved = s, saved
+			}
+			a = b
+		}
+	}
+	if bestSaved*r.Dx() <= scrollCost {
+		return scroll{}, false
+	}
+	best.top += r.Min.Y
+	best.bottom += r.Min.Y
+	return best, true
+}
+
+// render appends the escape sequences that scroll the region: setting the
+// scrolling region, scrolling up (SU) or down (SD), and restoring the whole
+// display as the scrolling region.
+// The rows that scroll into view take the default background, and setting the
+// scrolling region sends the cursor home.
+func (s scroll) render(buf []byte, cur Cursor) ([]byte, Cursor) {
+	buf, cur = cur.Reset(buf)
+	buf = append(buf, "\033["...)
+	buf = append(buf, strconv.Itoa(s.top+1)...)
+	buf = append(buf, ";"...)
+	buf = append(buf, strconv.Itoa(s.bottom)...)
+	buf = append(buf, "r\033["...)
+	if s.dy > 0 {
+		buf = append(buf, strconv.Itoa(s.dy)...)
+		buf = append(buf, "S"...)
+	} else {
+		buf = append(buf, strconv.Itoa(-s.dy)...)
+		buf = append(buf, "T"...)
+	}
+	buf = append(buf, "\033[r"...)
+	cur.Position = image.ZP
+	return buf, cur
+}
+
+// apply returns a copy of a model of the terminal as it is after the scroll,
+// with empty rows where rows scrolled into view.
+func (s scroll) apply(d *Display) *Display {
+	c := New(d.Rect)
+	for y := d.Rect.Min.Y; y < d.Rect.Max.Y; y++ {
+		from := y
+		if y >= s.top && y < s.bottom {
+			from = y + s.dy
+			if from < s.top || from >= s.bottom {
+				continue
+			}
+		}
+		for x := d.Rect.Min.X; x < d.Rect.Max.X; x++ {
+			c.Text.Set(x, y, d.Text.At(x, from))
+			c.Foreground.SetRGBA(x, y, d.Foreground.RGBAAt(x, from))
+			c.Background.SetRGBA(x, y, d.Background.RGBAAt(x, from))
+			c.Attributes.Set(x, y, d.Attributes.At(x, from))
+		}
+	}
+	return c
+}
+
+// hashRow returns a hash of the cells of a row, to quickly rule out rows that
+// differ.
+func hashRow(d *Display, y int) uint64 {
+	h := fnv.New64a()
+	var cell [binary.MaxVarintLen64 + 9]byte
+	for x := d.Rect.Min.X; x < d.Rect.Max.X; x++ {
+		t := d.Text.At(x, y)
+		f := d.Foreground.RGBAAt(x, y)
+		b := d.Background.RGBAAt(x, y)
+		// The length of the text precedes it, since the text may contain
+		// any byte, even zero, as in a continuation.
+		n := binary.PutUvarint(cell[:], uint64(len(t)))
+		n += copy(cell[n:], []byte{f.R, f.G, f.B, f.A, b.R, b.G, b.B, b.A, byte(d.Attributes.At(x, y))})
+		h.Write(cell[:n])
+		h.Write([]byte(t))
+	}
+	return h.Sum64()
+}
+
+// sameRow returns whether a row of one display has exactly the same cells as
+// a row of another display of the same width.
+func sameRow(a *Display, ay int, b *Display, by int) bool {
+	for x := a.Rect.Min.X; x < a.Rect.Max.X; x++ {
+		if a.Text.At(x, ay) != b.Text.At(x, by) ||
+			a.Foreground.RGBAAt(x, ay) != b.Foreground.RGBAAt(x, by) ||
+			a.Background.RGBAAt(x, ay) != b.Background.RGBAAt(x, by) ||
+			a.Attributes.At(x, ay) != b.Attributes.At(x, by) {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
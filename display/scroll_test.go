@@ -0,0 +1,58 @@
+package display
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lines returns a display with a line of text in each row.
+func lines(w int, rows ...string) *Display {
+	d := New(image.Rect(0, 0, w, len(rows)))
+	for y, row := range rows {
+		for x, r := range row {
+			d.Set(x, y, string(r), Colors[7], Colors[0])
+		}
+	}
+	return d
+}
+
+func TestFindScroll(t *testing.T) {
+	under := lines(20, "aaaa", "bbbb", "cccc", "dddd", "eeee")
+
+	s, ok := findScroll(lines(20, "bbbb", "cccc", "dddd", "eeee", "ffff"), under)
+	assert.True(t, ok)
+	assert.Equal(t, scroll{top: 0, bottom: 5, dy: 1}, s)
+
+	// A status line at the bottom stays put while the rest scrolls down.
+	s, ok = findScroll(lines(20, "zzzz", "aaaa", "bbbb", "cccc", "eeee"), under)
+	assert.True(t, ok)
+	assert.Equal(t, scroll{top: 0, bottom: 4, dy: -1}, s)
+
+	_, ok = findScroll(lines(20, "aaaa", "bbbb", "xxxx", "dddd", "eeee"), under)
+	assert.False(t, ok)
+}
+
+func TestRenderScrolling(t *testing.T) {
+	under := lines(20, "aaaa", "bbbb", "cccc", "dddd")
+	over := lines(20, "bbbb", "cccc", "dddd", "eeee")
+	buf, _ := RenderScrolling(nil, Reset, over, under, Model24)
+	assert.True(t, strings.HasPrefix(string(buf), "\033[1;4r\033[1S\033[r"), "%q", buf)
+	assert.NotContains(t, string(buf), "b")
+	assert.Contains(t, string(buf), "eeee")
+}
+
+func TestHashRowCellBoundaries(t *testing.T) {
+	// Text may contain zero bytes, like a continuation, so the hash must not
+	// confuse where one cell's text ends and the next begins.
+	zeros := strings.Repeat("\x00", 10)
+	a := New(image.Rect(0, 0, 2, 1))
+	a.Text.Set(0, 0, "a")
+	a.Text.Set(1, 0, zeros+"c")
+	b := New(image.Rect(0, 0, 2, 1))
+	b.Text.Set(0, 0, "a"+zeros)
+	b.Text.Set(1, 0, "c")
+	assert.NotEqual(t, hashRow(a, 0), hashRow(b, 0))
+}
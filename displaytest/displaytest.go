@@ -0,0 +1,201 @@
+// Package displaytest provides snapshot testing for displays.
+//
+// Snapshot renders a display as readable text: a grid of the text layer, a
+// grid for each of the foreground, background, and attribute layers, keyed by
+// a legend of the colors and attributes they use.
+// Golden compares the snapshot of a display to a golden file under the
+// testdata directory of the package under test.
+//
+//	func TestPanel(t *testing.T) {
+//		d := display.New(image.Rect(0, 0, 20, 5))
+//		drawPanel(d)
+//		displaytest.Golden(t, "panel", d)
+//	}
+//
+// Run the tests of a package with the -update flag to write its golden files
+// from the current snapshots, then review the changes to the golden files.
+// Only packages whose tests import displaytest accept the flag.
+//
+//	go test ./widget -update
+//
+// Terminal is a headless virtual terminal, for verifying that the output of
+// "display".Render reproduces the display it was rendered from.
+package displaytest
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/textile"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "write golden display snapshots to testdata")
+
+// Empty stands for an empty, transparent cell in the text layer of a snapshot.
+const Empty = "·"
+
+const (
+	colorKeys = "abcdefghijklmnopqrstuvwxyz0123456789"
+	attrKeys  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// Golden compares the snapshot of a display to the golden file
+// testdata/<name>.golden, failing the test if they differ or if the golden
+// file does not exist.
+// With the -update flag, Golden writes the golden file instead.
+func Golden(t testing.TB, name string, d *display.Display) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	got := Snapshot(d)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read golden display snapshot, run with -update to create it: %v", err)
+	}
+	assert.Equal(t, string(want), got, "display snapshot differs from %s, run with -update to accept it", path)
+}
+
+// Snapshot renders a display as text, for comparison with a golden file.
+//
+// The text grid shows the text layer between bars, with Empty for empty
+// cells and each wide glyph spanning its own cell and its continuation.
+// The color grids show a key for the color of each cell, or "." for
+// transparent, and the attribute grid a key for the attributes of each cell,
+// or "." for none.
+// The legends that follow give the color, as RGBA hex and palette index if
+// any, and the attribute names, for each key.
+func Snapshot(d *display.Display) string {
+	var s strings.Builder
+	colors := legend{keys: colorKeys}
+	attrs := legend{keys: attrKeys}
+	r := d.Bounds()
+
+	s.WriteString("text\n")
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		s.WriteByte('|')
+		for x := r.Min.X; x < r.Max.X; x++ {
+			t := d.Text.At(x, y)
+			switch {
+			case t == "":
+				s.WriteString(Empty)
+			case t == textile.Continuation:
+				if x == r.Min.X || textile.Width(d.Text.At(x-1, y)) != 2 {
+					s.WriteString(Empty)
+				}
+			default:
+				s.WriteString(t)
+			}
+		}
+		s.WriteString("|\n")
+	}
+
+	layer := func(title string, key func(x, y int) byte) {
+		s.WriteString(title + "\n")
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			s.WriteByte('|')
+			for x := r.Min.X; x < r.Max.X; x++ {
+				s.WriteByte(key(x, y))
+			}
+			s.WriteString("|\n")
+		}
+	}
+	layer("foreground", func(x, y int) byte {
+		return colors.key(colorName(d.Foreground.RGBAAt(x, y)))
+	})
+	layer("background", func(x, y int) byte {
+		return colors.key(colorName(d.Background.RGBAAt(x, y)))
+	})
+	layer("attributes", func(x, y int) byte {
+		return attrs.key(attrName(d.Attributes.At(x, y)))
+	})
+
+	colors.write(&s, "colors")
+	attrs.write(&s, "attribute keys")
+	return s.String()
+}
+
+// legend assigns a key to each distinct value in order of first appearance.
+type legend struct {
+	keys   string
+	values []string
+}
+
+// key returns the key for a value, or "." for the empty value.
+// Keys repeat if a snapshot uses more distinct values than keys.
+func (l *legend) key(value string) byte {
+	if value == "" {
+		return '.'
+	}
+	for i, v := range l.values {
+		if v == value {
+			return l.keys[i%len(l.keys)]
+		}
+	}
+	l.values = append(l.values, value)
+	return l.keys[(len(l.values)-1)%len(l.keys)]
+}
+
+func (l *legend) write(s *strings.Builder, title string) {
+	if len(l.values) == 0 {
+		return
+	}
+	s.WriteString(title + "\n")
+	for i, v := range l.values {
+		fmt.Fprintf(s, "%c %s\n", l.keys[i%len(l.keys)], v)
+	}
+}
+
+// colorName returns the hex RGBA of a color and its palette index, if it is
+// one of the palette colors, or an empty string for transparent.
+func colorName(c color.RGBA) string {
+	if c.A == 0 {
+		return ""
+	}
+	name := fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+	for i, p := range display.Colors {
+		if p == c {
+			return fmt.Sprintf("%s %d", name, i)
+		}
+	}
+	return name
+}
+
+var attrNames = []struct {
+	attr display.Attr
+	name string
+}{
+	{display.Bold, "bold"},
+	{display.Italic, "italic"},
+	{display.Underline, "underline"},
+	{display.Blink, "blink"},
+	{display.Reverse, "reverse"},
+	{display.Strikethrough, "strikethrough"},
+	{display.Dim, "dim"},
+}
+
+// attrName returns the names of the attributes in a set, or an empty string
+// for none.
+func attrName(a display.Attr) string {
+	var names []string
+	for _, n := range attrNames {
+		if a&n.attr != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, " ")
+}
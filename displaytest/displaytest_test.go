@@ -0,0 +1,52 @@
+package displaytest
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/textile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	d := display.New(image.Rect(0, 0, 4, 2))
+	d.Set(0, 0, "<", display.Colors[1], display.Colors[4])
+	d.SetAttr(0, 0, display.Bold)
+	d.Set(1, 0, "世", display.Colors[7], display.Colors[4])
+	d.Set(2, 0, textile.Continuation, display.Colors[7], display.Colors[4])
+	d.Set(1, 1, " ", color.RGBA{0x10, 0x20, 0x30, 0xff}, display.Transparent)
+	d.SetAttr(1, 1, display.Bold|display.Underline)
+
+	assert.Equal(t, `text
+|<世·|
+|· ··|
+foreground
+|abb.|
+|.c..|
+background
+|ddd.|
+|....|
+attributes
+|A...|
+|.B..|
+colors
+a #800000ff 1
+b #c0c0c0ff 7
+c #102030ff
+d #000080ff 4
+attribute keys
+A bold
+B bold underline
+`, Snapshot(d))
+}
+
+func TestGolden(t *testing.T) {
+	d := display.New(image.Rect(0, 0, 12, 3))
+	d.Fill(d.Bounds(), " ", display.Colors[7], display.Colors[4])
+	d.Fill(image.Rect(1, 1, 11, 2), "=", display.Colors[11], display.Colors[0])
+	d.Set(4, 1, "o", display.Colors[15], display.Colors[0])
+	d.SetAttr(4, 1, display.Bold|display.Blink)
+	Golden(t, "golden", d)
+}
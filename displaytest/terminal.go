@@ -0,0 +1,134 @@
+package displaytest
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/vtio"
+)
+
+// Terminal is a headless virtual terminal for verifying that rendered output
+// reproduces the display it was rendered from.
+// Write the output of "display".Render to the terminal, then compare the
+// terminal's screen to the display with Diff.
+//
+//	term := displaytest.NewTerminal(bounds)
+//	buf, cur = display.RenderOver(buf, cur, front, back, display.Model24)
+//	term.Write(buf)
+//	assert.NoError(t, term.Diff(front))
+//
+// The terminal interprets output with a "vtio".DisplayWriter.
+type Terminal struct {
+	w    *vtio.DisplayWriter
+	rect image.Rectangle
+}
+
+// NewTerminal returns a headless terminal with a blank screen of the given
+// size.
+func NewTerminal(r image.Rectangle) *Terminal {
+	return &Terminal{w: vtio.NewDisplayWriter(r), rect: r}
+}
+
+// Write interprets terminal output, updating the screen.
+func (t *Terminal) Write(p []byte) (int, error) {
+	return t.w.Write(p)
+}
+
+// Display returns a copy of the terminal's screen.
+func (t *Terminal) Display() *display.Display {
+	d := display.New(t.rect)
+	t.w.Draw(d, t.rect)
+	return d
+}
+
+// Cursor returns the position of the terminal's cursor, to verify where
+// rendering leaves it.
+func (t *Terminal) Cursor() image.Point {
+	return t.w.Cursor().Position
+}
+
+// Diff compares the terminal's screen to the display that the output was
+// rendered from, returning a *Mismatch for the first cell that differs, in
+// reading order, or nil if the screen reproduces the display.
+//
+// Diff compares the text of each cell as "display".Render renders it, with a
+// space in place of empty text, and compares colors as the 24 bit color model
+// renders them, ignoring their alpha channel, and compares text attributes,
+// like bold and underline.
+// Diff ignores what does not show on a blank cell: its foreground, unless
+// reversed, and attributes other than underline, reverse, and strikethrough.
+// Like xterm, the terminal brightens bold text in the first eight colors, so
+// Diff reports such cells in the brighter color.
+func (t *Terminal) Diff(want *display.Display) error {
+	got := t.Display()
+	r := want.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			w := cellAt(want, x, y)
+			g := cellAt(got, x, y)
+			if w != g {
+				return &Mismatch{Point: image.Pt(x, y), Want: w, Got: g}
+			}
+		}
+	}
+	return nil
+}
+
+// Cell is the text and colors of a cell of a screen, as a terminal renders
+// it.
+type Cell struct {
+	Text       string
+	Foreground color.RGBA
+	Background color.RGBA
+	Attr       display.Attr
+}
+
+func (c Cell) String() string {
+	s := fmt.Sprintf("%q fg %s bg %s", c.Text, hex(c.Foreground), hex(c.Background))
+	if c.Attr != 0 {
+		s += " " + attrName(c.Attr)
+	}
+	return s
+}
+
+// visibleOnBlank are the attributes that show on a blank cell.
+const visibleOnBlank = display.Underline | display.Reverse | display.Strikethrough
+
+// cellAt returns the rendered appearance of a cell of a display.
+func cellAt(d *display.Display, x, y int) Cell {
+	t, _ := d.GlyphAt(x, y)
+	_, f, b := d.At(x, y)
+	a := d.AttrAt(x, y)
+	if t == " " {
+		a &= visibleOnBlank
+		if a&display.Reverse == 0 {
+			return Cell{Text: t, Background: opaque(b), Attr: a}
+		}
+	}
+	return Cell{Text: t, Foreground: opaque(f), Background: opaque(b), Attr: a}
+}
+
+// opaque returns the color that the 24 bit color model renders, which
+// discards the alpha channel of a premultiplied color.
+func opaque(c color.Color) color.RGBA {
+	r, g, b, _ := c.RGBA()
+	return color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 255}
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Mismatch is the error Diff returns for the first cell of a terminal's
+// screen that differs from the expected display.
+type Mismatch struct {
+	Point image.Point
+	Want  Cell
+	Got   Cell
+}
+
+func (m *Mismatch) Error() string {
+	return fmt.Sprintf("cell %v differs: want %v, got %v", m.Point, m.Want, m.Got)
+}
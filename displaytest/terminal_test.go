@@ -0,0 +1,173 @@
+package displaytest
+
+import (
+	"image"
+	"image/draw"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/text"
+	"github.com/kriskowal/cops/textile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminalRender(t *testing.T) {
+	bounds := image.Rect(0, 0, 10, 4)
+	term := NewTerminal(bounds)
+	front, back := display.New2(bounds)
+	var buf []byte
+	cur := display.Start
+
+	front.Fill(bounds, " ", display.Colors[7], display.Colors[4])
+	text.Write(front, image.Rect(1, 1, 9, 3), "世界 cops\nsé👍🏽!", display.Colors[11])
+	buf, cur = display.RenderOver(buf, cur, front, back, display.Model24)
+	term.Write(buf)
+	assert.NoError(t, term.Diff(front))
+
+	front, back = back, front
+	display.Draw(front, bounds, back, image.ZP, draw.Src)
+	front.Set(9, 3, "x", display.Colors[1], display.Colors[0])
+	front.Set(0, 2, "y", display.Colors[1], display.Colors[0])
+	buf, cur = display.RenderOver(buf[0:0], cur, front, back, display.Model24)
+	term.Write(buf)
+	assert.NoError(t, term.Diff(front))
+}
+
+func TestTerminalRenderScrolling(t *testing.T) {
+	bounds := image.Rect(0, 0, 10, 5)
+	term := NewTerminal(bounds)
+	front, back := display.New2(bounds)
+	var buf []byte
+	cur := display.Start
+
+	log := []string{"one", "two", "three", "four", "five", "six", "seven"}
+	draw := func(first int) {
+		front.Clear(bounds)
+		text.Write(front, image.Rect(0, 0, 10, 4), strings.Join(log[first:first+4], "\n"), display.Colors[7])
+		text.Write(front, image.Rect(0, 4, 10, 5), "status", display.Colors[3])
+	}
+	draw(0)
+	buf, cur = display.RenderScrolling(buf, cur, front, back, display.Model24)
+	term.Write(buf)
+	assert.NoError(t, term.Diff(front))
+
+	for first := 1; first <= 3; first++ {
+		front, back = back, front
+		draw(first)
+		repaint, _ := display.RenderOver(nil, cur, front, back, display.Model24)
+		buf, cur = display.RenderScrolling(buf[0:0], cur, front, back, display.Model24)
+		assert.Less(t, len(buf), len(repaint))
+		term.Write(buf)
+		assert.NoError(t, term.Diff(front))
+	}
+}
+
+func TestTerminalRenderLastColumn(t *testing.T) {
+	bounds := image.Rect(0, 0, 10, 3)
+	term := NewTerminal(bounds)
+	front, back := display.New2(bounds)
+	var buf []byte
+	cur := display.Start
+
+	for i, x := range []int{9, 1, 9, 8} {
+		front.Set(x, 1, string(rune('a'+i)), display.Colors[7], display.Colors[0])
+		buf, cur = display.RenderOver(buf[0:0], cur, front, back, display.Model24)
+		term.Write(buf)
+		assert.NoError(t, term.Diff(front))
+		front, back = back, front
+		display.Draw(front, bounds, back, image.ZP, draw.Src)
+	}
+}
+
+func TestTerminalRenderRuns(t *testing.T) {
+	bounds := image.Rect(0, 0, 40, 8)
+	term := NewTerminal(bounds)
+	front, back := display.New2(bounds)
+	var buf []byte
+	cur := display.Start
+
+	// Frames of runs of blanks and repeated characters in a few colors,
+	// varying a little from frame to frame, exercise erasing, repeating, and
+	// rewriting the cells between changes.
+	rng := rand.New(rand.NewSource(1))
+	glyphs := []string{" ", " ", "=", "a", "日"}
+	attrs := []display.Attr{0, 0, 0, display.Bold, display.Underline, display.Dim | display.Italic, display.Reverse, display.Strikethrough}
+	for frame := 0; frame < 200; frame++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; {
+				n := 1 + rng.Intn(12)
+				if frame > 0 && rng.Intn(4) > 0 {
+					// Leave the run as it was.
+					display.Draw(front, image.Rect(x, y, x+n, y+1), back, image.Pt(x, y), draw.Src)
+					x += n
+					continue
+				}
+				g := glyphs[rng.Intn(len(glyphs))]
+				fg := display.Colors[rng.Intn(3)+1]
+				bg := display.Colors[rng.Intn(2)]
+				attr := attrs[rng.Intn(len(attrs))]
+				if attr&display.Bold != 0 {
+					// The terminal brightens bold text in the first eight
+					// colors.
+					fg = display.Colors[rng.Intn(3)+9]
+				}
+				for i := 0; i < n && x < bounds.Max.X; i++ {
+					if textile.Width(g) == 2 && x+1 < bounds.Max.X {
+						front.Set(x, y, g, fg, bg)
+						front.SetAttr(x, y, attr)
+						front.Set(x+1, y, textile.Continuation, fg, bg)
+						front.SetAttr(x+1, y, attr)
+						x += 2
+						continue
+					}
+					front.Set(x, y, strings.Replace(g, "日", "b", 1), fg, bg)
+					front.SetAttr(x, y, attr)
+					x++
+				}
+			}
+		}
+		buf, cur = display.RenderCompressed(buf[0:0], cur, front, back, display.Model24,
+			display.CompressErase|display.CompressRepeat|display.CompressEraseLine)
+		term.Write(buf)
+		if !assert.NoError(t, term.Diff(front), "frame %d", frame) {
+			break
+		}
+		front, back = back, front
+	}
+}
+
+func TestTerminalMismatch(t *testing.T) {
+	bounds := image.Rect(0, 0, 3, 2)
+	term := NewTerminal(bounds)
+	want := display.New(bounds)
+	want.Fill(bounds, "a", display.Colors[7], display.Colors[0])
+	term.Write([]byte("\033[1;1Haaa\r\na\033[31mba"))
+	err := term.Diff(want)
+	assert.Equal(t, &Mismatch{
+		Point: image.Pt(1, 1),
+		Want:  Cell{Text: "a", Foreground: display.Colors[7], Background: display.Colors[0]},
+		Got:   Cell{Text: "b", Foreground: display.Colors[1], Background: display.Colors[0]},
+	}, err)
+	assert.EqualError(t, err, `cell (1,1) differs: want "a" fg #c0c0c0 bg #000000, got "b" fg #800000 bg #000000`)
+}
+
+func TestTerminalMismatchAttributes(t *testing.T) {
+	bounds := image.Rect(0, 0, 3, 1)
+	term := NewTerminal(bounds)
+	want := display.New(bounds)
+	want.Fill(bounds, "a", display.Colors[7], display.Colors[0])
+	want.Set(2, 0, " ", display.Colors[7], display.Colors[0])
+	want.SetAttr(2, 0, display.Bold)
+	term.Write([]byte("\033[1;1Ha\033[1;4ma\033[22m "))
+	err := term.Diff(want)
+	assert.EqualError(t, err, `cell (1,0) differs: want "a" fg #c0c0c0 bg #000000, got "a" fg #c0c0c0 bg #000000 bold underline`)
+
+	// Bold does not show on a blank, but underline does.
+	term.Write([]byte("\033[1;2H\033[mA\033[1m "))
+	want.Set(1, 0, "A", display.Colors[7], display.Colors[0])
+	assert.NoError(t, term.Diff(want))
+	term.Write([]byte("\033[1;3H\033[4m "))
+	assert.Error(t, term.Diff(want))
+}
@@ -18,6 +18,22 @@
 // The "terminal" package provides an idiomatic Go interface for terminal
 // capabilities ("raw mode", "no echo", getting and setting size).
 //
+// The "screen" package runs a full-screen application, rendering each frame
+// of a display to the terminal, or renders inline beneath the shell prompt.
+//
+// The "input" package decodes raw terminal input into key events.
+//
+// The "vtio" package interprets the output of other programs, with ANSI escape
+// sequences, onto a display.
+//
+// The "mux" package runs programs on pseudo-terminals in panes of a single
+// display.
+//
+// The "export" package encodes displays as ANSI text, HTML, and SVG.
+//
+// The "displaytest" package compares snapshots of displays to golden files
+// in tests.
+//
 // The "rectangle" package provides conveniences for manipulating image
 // rectangles for display composition.
 //
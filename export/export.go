@@ -0,0 +1,148 @@
+// Package export encodes displays as self-contained documents, for
+// publishing snapshots of terminal user interfaces outside a terminal.
+//
+// ANSI writes a display as lines of text with the escape sequences for colors
+// and attributes, suitable for printing to a terminal or a log.
+// HTML writes a display as a preformatted block with inline styles.
+// SVG writes a display as a vector image with a rectangle for each run of
+// background color and text for each run of glyphs.
+//
+// Each encoder renders the cells of a display just as "display".Render would,
+// skipping the continuation cells of wide glyphs and rendering blanks for
+// empty cells.
+package export
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/textile"
+)
+
+// ANSI writes a display as a sequence of newline terminated lines of text,
+// setting colors and attributes with SGR escape sequences in the given color
+// model, and resetting them at the end of each line.
+// Unlike "display".Render, the output does not move the cursor, so it prints
+// in place wherever the cursor happens to be, line after line.
+func ANSI(w io.Writer, d *display.Display, model display.Model) error {
+	var buf []byte
+	plain := display.Cursor{Position: display.Lost}
+	for y := d.Rect.Min.Y; y < d.Rect.Max.Y; y++ {
+		cur := plain
+		colored := false
+		for x := d.Rect.Min.X; x < d.Rect.Max.X; x++ {
+			t, covered := d.GlyphAt(x, y)
+			if covered {
+				continue
+			}
+			_, f, b := d.At(x, y)
+			a := d.AttrAt(x, y)
+			buf, cur = cur.SetAttr(buf, a)
+			n := len(buf)
+			buf, cur = model.Render(buf, cur, f, b)
+			colored = colored || len(buf) > n
+			buf = append(buf, t...)
+		}
+		if colored || cur.Attributes != plain.Attributes {
+			buf = append(buf, "\033[m"...)
+		}
+		buf = append(buf, '\n')
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// cell is a glyph of a display, the number of cells it occupies, and its
+// style.
+type cell struct {
+	text  string
+	width int
+	style style
+}
+
+// style is the resolved appearance of a cell.
+// Reverse video and dimming are already applied to the colors, so the
+// attributes never include Reverse or Dim.
+type style struct {
+	fg, bg color.RGBA
+	attr   display.Attr
+}
+
+// cells returns the glyphs of a row of a display, in order, omitting the cells
+// covered by wide glyphs.
+func cells(d *display.Display, y int) []cell {
+	var row []cell
+	for x := d.Rect.Min.X; x < d.Rect.Max.X; x++ {
+		t, covered := d.GlyphAt(x, y)
+		if covered {
+			continue
+		}
+		_, f, b := d.At(x, y)
+		a := d.AttrAt(x, y)
+		w := textile.Width(t)
+		if w < 1 {
+			w = 1
+		}
+		row = append(row, cell{text: t, width: w, style: cellStyle(f, b, a)})
+	}
+	return row
+}
+
+// cellStyle resolves the colors and attributes of a cell, swapping the
+// foreground and background for reverse video and darkening the foreground of
+// dim text.
+// A reversed transparent background becomes black text.
+func cellStyle(f, b color.Color, a display.Attr) style {
+	s := style{
+		fg:   color.RGBAModel.Convert(f).(color.RGBA),
+		bg:   color.RGBAModel.Convert(b).(color.RGBA),
+		attr: a &^ display.Reverse,
+	}
+	if a&display.Reverse != 0 {
+		s.fg, s.bg = s.bg, s.fg
+		if s.fg.A == 0 {
+			s.fg = display.Colors[0]
+		}
+	}
+	if a&display.Dim != 0 {
+		// Dim text is half as intense.
+		s.fg = color.RGBA{s.fg.R / 2, s.fg.G / 2, s.fg.B / 2, s.fg.A}
+		s.attr &^= display.Dim
+	}
+	return s
+}
+
+// cssColor formats a color for CSS and SVG, as an RGB hex triple if it is
+// opaque or with its opacity if it is translucent.
+func cssColor(c color.RGBA) string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	// color.RGBA is alpha premultiplied, but CSS colors are not.
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("rgba(%d,%d,%d,%.3g)", n.R, n.G, n.B, float64(n.A)/255)
+}
+
+// textDecoration returns the CSS text decoration for the attributes of a
+// style, or an empty string if none apply.
+func textDecoration(a display.Attr) string {
+	var d string
+	for _, dec := range []struct {
+		attr display.Attr
+		name string
+	}{
+		{display.Underline, "underline"},
+		{display.Strikethrough, "line-through"},
+		{display.Blink, "blink"},
+	} {
+		if a&dec.attr != 0 {
+			if d != "" {
+				d += " "
+			}
+			d += dec.name
+		}
+	}
+	return d
+}
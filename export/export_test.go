@@ -0,0 +1,73 @@
+package export
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/textile"
+	"github.com/stretchr/testify/assert"
+)
+
+// sample returns a display with a bold glyph, a wide glyph on a blue
+// background, and a reversed underlined glyph over transparency.
+func sample() *display.Display {
+	d := display.New(image.Rect(0, 0, 4, 2))
+	d.Set(0, 0, "<", display.Colors[1], display.Colors[4])
+	d.SetAttr(0, 0, display.Bold)
+	d.Set(1, 0, "世", display.Colors[7], display.Colors[4])
+	d.Set(2, 0, textile.Continuation, display.Colors[7], display.Colors[4])
+	d.Set(1, 1, "x", display.Colors[2], display.Transparent)
+	d.SetAttr(1, 1, display.Reverse|display.Underline)
+	return d
+}
+
+func TestANSI(t *testing.T) {
+	d := display.New(image.Rect(0, 0, 2, 2))
+	d.Set(0, 0, "a", display.Colors[1], display.Colors[0])
+	d.SetAttr(0, 0, display.Bold)
+	d.Set(1, 0, "b", display.Colors[1], display.Colors[0])
+	d.Fill(image.Rect(0, 1, 2, 2), "c", display.Colors[7], display.Colors[0])
+	var buf bytes.Buffer
+	assert.NoError(t, ANSI(&buf, d, display.Model4))
+	assert.Equal(t, "\033[1m\033[31m\033[40ma\033[22mb\033[m\n\033[37m\033[40mcc\033[m\n", buf.String())
+}
+
+func TestANSIWide(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, ANSI(&buf, sample(), display.Model0))
+	assert.Equal(t, "\033[1m<\033[22m世 \n \033[4;7mx\033[24;27m  \n", buf.String())
+}
+
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, HTML(&buf, sample()))
+	assert.Equal(t, `<pre style="background-color:#000000;color:#c0c0c0">`+
+		`<span style="color:#800000;background-color:#000080;font-weight:bold">&lt;</span>`+
+		`<span style="color:#c0c0c0;background-color:#000080">世</span> `+"\n"+
+		` <span style="color:#000000;background-color:#008000;text-decoration:underline">x</span>  `+
+		"</pre>\n", buf.String())
+}
+
+func TestSVG(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, SVG(&buf, sample(), image.Pt(8, 16)))
+	assert.Equal(t, `<svg xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32">
+<rect width="32" height="32" fill="#000000"/>
+<g font-family="monospace" font-size="12" xml:space="preserve">
+<rect x="0" y="0" width="24" height="16" fill="#000080"/>
+<text x="0" y="12" textLength="8" lengthAdjust="spacingAndGlyphs" fill="#800000" font-weight="bold">&lt;</text>
+<text x="8" y="12" textLength="16" lengthAdjust="spacingAndGlyphs" fill="#c0c0c0">世</text>
+<rect x="8" y="16" width="8" height="16" fill="#008000"/>
+<text x="8" y="28" textLength="8" lengthAdjust="spacingAndGlyphs" fill="#000000" text-decoration="underline">x</text>
+</g>
+</svg>
+`, buf.String())
+}
+
+func TestCSSColor(t *testing.T) {
+	assert.Equal(t, "#ff8000", cssColor(color.RGBA{255, 128, 0, 255}))
+	assert.Equal(t, "rgba(255,0,0,0.502)", cssColor(color.RGBA{128, 0, 0, 128}))
+}
@@ -0,0 +1,74 @@
+package export
+
+import (
+	"html"
+	"io"
+	"strings"
+
+	"github.com/kriskowal/cops/display"
+)
+
+// HTML writes a display as an HTML pre element, with a span with inline
+// styles for each run of cells with the same colors and attributes.
+// The pre element has a black background and white (7) text, and a
+// transparent background in the display lets that background show through.
+// The pre element needs a monospace font with glyphs for any wide
+// characters to line up like a terminal.
+func HTML(w io.Writer, d *display.Display) error {
+	var b strings.Builder
+	b.WriteString(`<pre style="background-color:`)
+	b.WriteString(cssColor(display.Colors[0]))
+	b.WriteString(";color:")
+	b.WriteString(cssColor(display.Colors[7]))
+	b.WriteString(`">`)
+	for y := d.Rect.Min.Y; y < d.Rect.Max.Y; y++ {
+		if y > d.Rect.Min.Y {
+			b.WriteByte('\n')
+		}
+		row := cells(d, y)
+		for len(row) > 0 {
+			n := 1
+			for n < len(row) && row[n].style == row[0].style {
+				n++
+			}
+			css := cssStyle(row[0].style)
+			if css != "" {
+				b.WriteString(`<span style="`)
+				b.WriteString(css)
+				b.WriteString(`">`)
+			}
+			for _, c := range row[:n] {
+				b.WriteString(html.EscapeString(c.text))
+			}
+			if css != "" {
+				b.WriteString("</span>")
+			}
+			row = row[n:]
+		}
+	}
+	b.WriteString("</pre>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// cssStyle returns the inline CSS for a style, omitting transparent colors,
+// or an empty string for the style of an empty cell.
+func cssStyle(s style) string {
+	var props []string
+	if s.fg.A != 0 {
+		props = append(props, "color:"+cssColor(s.fg))
+	}
+	if s.bg.A != 0 {
+		props = append(props, "background-color:"+cssColor(s.bg))
+	}
+	if s.attr&display.Bold != 0 {
+		props = append(props, "font-weight:bold")
+	}
+	if s.attr&display.Italic != 0 {
+		props = append(props, "font-style:italic")
+	}
+	if d := textDecoration(s.attr); d != "" {
+		props = append(props, "text-decoration:"+d)
+	}
+	return strings.Join(props, ";")
+}
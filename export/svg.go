@@ -0,0 +1,84 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/kriskowal/cops/display"
+)
+
+// SVG writes a display as an SVG image, with the given size in pixels for
+// each cell.
+// The image has a black background, with a rectangle for each run of cells
+// with the same background color, and a text element for each run of glyphs
+// with the same foreground color and attributes.
+// Each text element stretches to the width of its cells, so the glyphs line up
+// like a terminal regardless of the monospace font the viewer chooses.
+//
+//	export.SVG(w, front, image.Pt(8, 16))
+func SVG(w io.Writer, d *display.Display, cell image.Point) error {
+	var b strings.Builder
+	size := d.Rect.Size()
+	width, height := size.X*cell.X, size.Y*cell.Y
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`+"\n", width, height, cssColor(display.Colors[0]))
+	fmt.Fprintf(&b, `<g font-family="monospace" font-size="%d" xml:space="preserve">`+"\n", cell.Y*4/5)
+	for y := 0; y < size.Y; y++ {
+		row := cells(d, d.Rect.Min.Y+y)
+		top := y * cell.Y
+
+		// Backgrounds
+		for i, x := 0, 0; i < len(row); {
+			n, w := 1, row[i].width
+			for i+n < len(row) && row[i+n].style.bg == row[i].style.bg {
+				w += row[i+n].width
+				n++
+			}
+			if bg := row[i].style.bg; bg.A != 0 {
+				fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+					x*cell.X, top, w*cell.X, cell.Y, cssColor(bg))
+			}
+			i += n
+			x += w
+		}
+
+		// Text
+		for i, x := 0, 0; i < len(row); {
+			n, w := 1, row[i].width
+			text := row[i].text
+			for i+n < len(row) && row[i+n].style == row[i].style {
+				text += row[i+n].text
+				w += row[i+n].width
+				n++
+			}
+			if strings.TrimSpace(text) != "" {
+				fmt.Fprintf(&b, `<text x="%d" y="%d" textLength="%d" lengthAdjust="spacingAndGlyphs"%s>%s</text>`+"\n",
+					x*cell.X, top+cell.Y*4/5, w*cell.X, svgAttributes(row[i].style), html.EscapeString(text))
+			}
+			i += n
+			x += w
+		}
+	}
+	b.WriteString("</g>\n</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// svgAttributes returns the presentation attributes of a text element for a
+// style.
+func svgAttributes(s style) string {
+	a := ` fill="` + cssColor(s.fg) + `"`
+	if s.attr&display.Bold != 0 {
+		a += ` font-weight="bold"`
+	}
+	if s.attr&display.Italic != 0 {
+		a += ` font-style="italic"`
+	}
+	if d := textDecoration(s.attr); d != "" {
+		a += ` text-decoration="` + d + `"`
+	}
+	return a
+}
@@ -0,0 +1,387 @@
+// Package input decodes raw terminal input into key and mouse events.
+//
+// A terminal in raw mode, as set by the "terminal" package, sends printable
+// characters as UTF-8 and most other keys as control characters or escape
+// sequences. The escape key sends a lone escape byte, which is also the
+// prefix of every other escape sequence, so decoding a lone escape requires
+// waiting a moment to see whether more bytes follow.
+//
+// Mouse events, bracketed paste, and focus changes arrive only after asking
+// the terminal to report them, as with the EnableMouse, EnableBracketedPaste,
+// and EnableFocusReporting methods of the display Cursor.
+package input
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Key identifies a key, or KeyRune for printable characters and control
+// characters, in which case the Rune of the event is the character.
+type Key int
+
+const (
+	// KeyRune indicates that the event carries a rune, like 'a' or, with the
+	// Ctrl modifier, the 'c' of Ctrl+C.
+	KeyRune Key = iota
+	KeyEscape
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyUp
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
+	KeyInsert
+	KeyDelete
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	// KeyMouse indicates a mouse event, described by the Mouse of the event.
+	KeyMouse
+	// KeyPaste indicates bracketed paste, with the pasted text in the Text of
+	// the event.
+	KeyPaste
+	// KeyFocusIn and KeyFocusOut indicate that the terminal window gained or
+	// lost focus.
+	KeyFocusIn
+	KeyFocusOut
+)
+
+// Mod is a set of modifier keys held while pressing a key.
+type Mod uint8
+
+const (
+	Shift Mod = 1 << iota
+	Alt
+	Ctrl
+	Meta
+)
+
+// Event is a single key press, mouse event, paste, or focus change.
+type Event struct {
+	Key   Key
+	Rune  rune
+	Mod   Mod
+	Mouse Mouse
+	Text  string
+}
+
+// DefaultTimeout is a reasonable time to wait for the remainder of an escape
+// sequence before deciding that a lone escape byte was the escape key.
+const DefaultTimeout = 50 * time.Millisecond
+
+// Decode decodes the first event from the given bytes and returns the number
+// of bytes it consumed.
+//
+// If the bytes might be the beginning of a longer sequence, Decode returns 0,
+// expecting to be called again when more bytes arrive.
+// If final is true, no more bytes are forthcoming, so Decode takes the
+// shortest interpretation instead, like a lone escape as the escape key.
+// Decode returns 0 for an empty slice.
+// Decode consumes complete but unrecognized escape sequences, returning the
+// zero Event for them.
+func Decode(buf []byte, final bool) (Event, int) {
+	if len(buf) == 0 {
+		return Event{}, 0
+	}
+	b := buf[0]
+	if b == 0x1b {
+		return decodeEscape(buf, final)
+	}
+	if b < 0x20 || b == 0x7f {
+		return decodeControl(b), 1
+	}
+	if b < utf8.RuneSelf {
+		return Event{Key: KeyRune, Rune: rune(b)}, 1
+	}
+	if !final && !utf8.FullRune(buf) {
+		return Event{}, 0
+	}
+	r, n := utf8.DecodeRune(buf)
+	return Event{Key: KeyRune, Rune: r}, n
+}
+
+func decodeControl(b byte) Event {
+	switch b {
+	case '\r', '\n':
+		return Event{Key: KeyEnter}
+	case '\t':
+		return Event{Key: KeyTab}
+	case 0x08, 0x7f:
+		return Event{Key: KeyBackspace}
+	case 0x00:
+		return Event{Key: KeyRune, Rune: ' ', Mod: Ctrl}
+	case 0x1c, 0x1d, 0x1e, 0x1f:
+		return Event{Key: KeyRune, Rune: rune(b) - 0x1c + '\\', Mod: Ctrl}
+	}
+	return Event{Key: KeyRune, Rune: rune(b) - 1 + 'a', Mod: Ctrl}
+}
+
+func decodeEscape(buf []byte, final bool) (Event, int) {
+	if len(buf) == 1 {
+		if !final {
+			return Event{}, 0
+		}
+		return Event{Key: KeyEscape}, 1
+	}
+	switch buf[1] {
+	case '[':
+		if len(buf) > 2 && buf[2] == 'M' {
+			return decodeMouseX10(buf, final)
+		}
+		params, n := scanCSI(buf)
+		if n > 0 && buf[2] == '<' {
+			return decodeMouseSGR(buf[n-1], params), n
+		}
+		if n > 0 && buf[n-1] == '~' && len(params) == 1 && params[0] == 200 {
+			return decodePaste(buf, n, final)
+		}
+		if n > 0 {
+			// Unrecognized but complete sequences decode to the zero event,
+			// so they do not arrive as a flurry of Alt+[ and printable keys.
+			ev, _ := csiKey(buf[n-1], params)
+			return ev, n
+		}
+		if n == 0 && !final {
+			return Event{}, 0
+		}
+	case 'O':
+		if len(buf) < 3 {
+			if !final {
+				return Event{}, 0
+			}
+		} else if key, ok := ss3Keys[buf[2]]; ok {
+			return Event{Key: key}, 3
+		}
+	}
+	// An escape followed by any other key is that key with the Alt modifier.
+	ev, n := Decode(buf[1:], final)
+	if n == 0 {
+		return ev, 0
+	}
+	ev.Mod |= Alt
+	return ev, n + 1
+}
+
+// pasteEnd is the sequence that ends bracketed paste, which begins with
+// "\x1b[200~".
+const pasteEnd = "\x1b[201~"
+
+// decodePaste decodes bracketed paste, with the text following the given
+// number of bytes of the opening sequence.
+// If the input pauses before the end of the paste, the text so far is
+// decoded as a paste, and the remainder of the paste will arrive as keys.
+func decodePaste(buf []byte, n int, final bool) (Event, int) {
+	text := string(buf[n:])
+	if i := strings.Index(text, pasteEnd); i >= 0 {
+		return Event{Key: KeyPaste, Text: text[:i]}, n + i + len(pasteEnd)
+	}
+	if !final {
+		return Event{}, 0
+	}
+	return Event{Key: KeyPaste, Text: text}, len(buf)
+}
+
+// scanCSI finds the end of a control sequence beginning with escape and
+// bracket, like "\x1b[A" for the up arrow or "\x1b[1;5A" for Ctrl+Up, and
+// parses its numeric parameters.
+// Returns 0 if the sequence is incomplete, or -1 if a control character
+// interrupts it.
+func scanCSI(buf []byte) ([]int, int) {
+	var params []int
+	param, digits := 0, false
+	for i := 2; i < len(buf); i++ {
+		b := buf[i]
+		switch {
+		case b >= '0' && b <= '9':
+			param = param*10 + int(b-'0')
+			digits = true
+		case b == ';' || b == ':':
+			params = append(params, param)
+			param, digits = 0, false
+		case b >= 0x40 && b <= 0x7e:
+			if digits || len(params) > 0 {
+				params = append(params, param)
+			}
+			return params, i + 1
+		case b < 0x20:
+			return nil, -1
+		}
+	}
+	return nil, 0
+}
+
+func csiKey(final byte, params []int) (Event, bool) {
+	var ev Event
+	if key, ok := ss3Keys[final]; ok {
+		ev.Key = key
+	} else if final == 'Z' {
+		ev.Key = KeyTab
+		ev.Mod = Shift
+	} else if final == 'I' {
+		ev.Key = KeyFocusIn
+	} else if final == 'O' {
+		ev.Key = KeyFocusOut
+	} else if final == '~' && len(params) > 0 {
+		if key, ok := tildeKeys[params[0]]; ok {
+			ev.Key = key
+		} else {
+			return ev, false
+		}
+	} else {
+		return ev, false
+	}
+	if len(params) > 1 && params[1] > 1 {
+		ev.Mod |= Mod(params[1] - 1)
+	}
+	return ev, true
+}
+
+// ss3Keys maps the final byte of "\x1bO" and "\x1b[" sequences to keys.
+var ss3Keys = map[byte]Key{
+	'A': KeyUp,
+	'B': KeyDown,
+	'C': KeyRight,
+	'D': KeyLeft,
+	'H': KeyHome,
+	'F': KeyEnd,
+	'P': KeyF1,
+	'Q': KeyF2,
+	'R': KeyF3,
+	'S': KeyF4,
+}
+
+// tildeKeys maps the first parameter of "\x1b[n~" sequences to keys.
+var tildeKeys = map[int]Key{
+	1:  KeyHome,
+	2:  KeyInsert,
+	3:  KeyDelete,
+	4:  KeyEnd,
+	5:  KeyPageUp,
+	6:  KeyPageDown,
+	7:  KeyHome,
+	8:  KeyEnd,
+	11: KeyF1,
+	12: KeyF2,
+	13: KeyF3,
+	14: KeyF4,
+	15: KeyF5,
+	17: KeyF6,
+	18: KeyF7,
+	19: KeyF8,
+	20: KeyF9,
+	21: KeyF10,
+	23: KeyF11,
+	24: KeyF12,
+}
+
+// Events reads raw terminal input and sends the decoded events on the
+// returned channel, and returns a function that stops reading.
+// The channel closes when reading fails, reaches the end of the input, or
+// stops.
+// A read in progress cannot be interrupted, so the reader stops, discarding
+// what it read, only when that read returns, as when the user next presses a
+// key.
+// If the input pauses in the middle of an ambiguous sequence, like a lone
+// escape byte, for longer than the timeout, the bytes so far are decoded
+// on their own.
+//
+//	term.SetRaw()
+//	events, stop := input.Events(os.Stdin, input.DefaultTimeout)
+//	defer stop()
+//	for ev := range events {
+//		if ev.Key == input.KeyRune && ev.Rune == 'c' && ev.Mod == input.Ctrl {
+//			break
+//		}
+//	}
+func Events(r io.Reader, timeout time.Duration) (<-chan Event, func()) {
+	events := make(chan Event)
+	chunks := make(chan []byte)
+	done := make(chan struct{})
+	go func() {
+		defer close(chunks)
+		for {
+			chunk := make([]byte, 256)
+			n, err := r.Read(chunk)
+			if n > 0 {
+				select {
+				case chunks <- chunk[:n]:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		defer close(events)
+		var buf []byte
+		var expired <-chan time.Time
+		for {
+			var ok bool
+			select {
+			case chunk, more := <-chunks:
+				if !more {
+					decodeAll(events, done, buf, true)
+					return
+				}
+				buf, ok = decodeAll(events, done, append(buf, chunk...), false)
+			case <-expired:
+				buf, ok = decodeAll(events, done, buf, true)
+			case <-done:
+				return
+			}
+			if !ok {
+				return
+			}
+			expired = nil
+			if len(buf) > 0 {
+				expired = time.After(timeout)
+			}
+		}
+	}()
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+}
+
+// decodeAll sends every event that can be decoded from the buffer and returns
+// the remaining bytes, or false if sending stopped.
+func decodeAll(events chan<- Event, done <-chan struct{}, buf []byte, final bool) ([]byte, bool) {
+	for {
+		ev, n := Decode(buf, final)
+		if n == 0 {
+			return buf, true
+		}
+		if ev != (Event{}) {
+			select {
+			case events <- ev:
+			case <-done:
+				return nil, false
+			}
+		}
+		buf = buf[n:]
+	}
+}
@@ -0,0 +1,172 @@
+package input
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeString(str string, final bool) []Event {
+	var events []Event
+	buf := []byte(str)
+	for {
+		ev, n := Decode(buf, final)
+		if n == 0 {
+			return events
+		}
+		events = append(events, ev)
+		buf = buf[n:]
+	}
+}
+
+func TestDecodeRunes(t *testing.T) {
+	assert.Equal(t, []Event{
+		{Key: KeyRune, Rune: 'a'},
+		{Key: KeyRune, Rune: 'é'},
+		{Key: KeyRune, Rune: '日'},
+	}, decodeString("aé日", false))
+}
+
+func TestDecodeControl(t *testing.T) {
+	assert.Equal(t, []Event{
+		{Key: KeyRune, Rune: 'c', Mod: Ctrl},
+		{Key: KeyEnter},
+		{Key: KeyTab},
+		{Key: KeyBackspace},
+		{Key: KeyRune, Rune: ' ', Mod: Ctrl},
+	}, decodeString("\x03\r\t\x7f\x00", false))
+}
+
+func TestDecodeKeys(t *testing.T) {
+	assert.Equal(t, []Event{
+		{Key: KeyUp},
+		{Key: KeyLeft},
+		{Key: KeyHome},
+		{Key: KeyF1},
+		{Key: KeyPageDown},
+		{Key: KeyF5},
+		{Key: KeyTab, Mod: Shift},
+	}, decodeString("\x1b[A\x1bOD\x1b[H\x1bOP\x1b[6~\x1b[15~\x1b[Z", false))
+}
+
+func TestDecodeModifiers(t *testing.T) {
+	assert.Equal(t, []Event{
+		{Key: KeyUp, Mod: Ctrl},
+		{Key: KeyDelete, Mod: Shift | Alt},
+		{Key: KeyRune, Rune: 'x', Mod: Alt},
+		{Key: KeyDown, Mod: Alt},
+	}, decodeString("\x1b[1;5A\x1b[3;4~\x1bx\x1b\x1b[B", false))
+}
+
+func TestDecodeEscapeAmbiguity(t *testing.T) {
+	assert.Equal(t, []Event(nil), decodeString("\x1b", false))
+	assert.Equal(t, []Event{{Key: KeyEscape}}, decodeString("\x1b", true))
+	assert.Equal(t, []Event(nil), decodeString("\x1b[1;", false))
+	assert.Equal(t, []Event{
+		{Key: KeyRune, Rune: '[', Mod: Alt},
+	}, decodeString("\x1b[", true))
+}
+
+func TestDecodeUnrecognized(t *testing.T) {
+	assert.Equal(t, []Event{
+		{},
+		{Key: KeyRune, Rune: 'a'},
+	}, decodeString("\x1b[99xa", false))
+}
+
+func TestEvents(t *testing.T) {
+	var events []Event
+	ch, stop := Events(bytes.NewReader([]byte("q\x1b[B\x1b")), time.Millisecond)
+	defer stop()
+	for ev := range ch {
+		events = append(events, ev)
+	}
+	assert.Equal(t, []Event{
+		{Key: KeyRune, Rune: 'q'},
+		{Key: KeyDown},
+		{Key: KeyEscape},
+	}, events)
+}
+
+func TestEventsStop(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	ch, stop := Events(r, time.Millisecond)
+	go w.Write([]byte("ab"))
+	assert.Equal(t, Event{Key: KeyRune, Rune: 'a'}, <-ch)
+
+	// The consumer stops before receiving every event.
+	stop()
+	stop()
+	closed := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("events not closed")
+	}
+
+	// The reader stops after its pending read.
+	done := make(chan struct{})
+	go func() {
+		w.Write([]byte("c"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reader not reading")
+	}
+	// Nothing reads any more.
+	wrote := make(chan error)
+	go func() {
+		_, err := w.Write([]byte("d"))
+		wrote <- err
+	}()
+	select {
+	case <-wrote:
+		t.Fatal("reader still reading")
+	case <-time.After(20 * time.Millisecond):
+	}
+	r.Close()
+	assert.Equal(t, io.ErrClosedPipe, <-wrote)
+}
+
+func TestDecodeMouseSGR(t *testing.T) {
+	assert.Equal(t, []Event{
+		{Key: KeyMouse, Mouse: Mouse{Action: MousePress, Button: ButtonLeft, Point: image.Pt(9, 4)}},
+		{Key: KeyMouse, Mouse: Mouse{Action: MouseDrag, Button: ButtonLeft, Point: image.Pt(10, 4)}},
+		{Key: KeyMouse, Mouse: Mouse{Action: MouseRelease, Button: ButtonLeft, Point: image.Pt(10, 4)}},
+		{Key: KeyMouse, Mouse: Mouse{Action: MouseMotion, Point: image.Pt(299, 99)}},
+		{Key: KeyMouse, Mod: Ctrl, Mouse: Mouse{Action: MouseWheel, Button: WheelDown, Point: image.Pt(0, 0)}},
+	}, decodeString("\x1b[<0;10;5M\x1b[<32;11;5M\x1b[<0;11;5m\x1b[<35;300;100M\x1b[<81;1;1M", false))
+}
+
+func TestDecodeMouseX10(t *testing.T) {
+	assert.Equal(t, []Event{
+		{Key: KeyMouse, Mouse: Mouse{Action: MousePress, Button: ButtonRight, Point: image.Pt(2, 3)}},
+		{Key: KeyMouse, Mouse: Mouse{Action: MouseRelease, Point: image.Pt(2, 3)}},
+	}, decodeString("\x1b[M\x22\x23\x24\x1b[M\x23\x23\x24", false))
+	assert.Equal(t, []Event(nil), decodeString("\x1b[M\x22", false))
+}
+
+func TestDecodePasteAndFocus(t *testing.T) {
+	assert.Equal(t, []Event{
+		{Key: KeyFocusIn},
+		{Key: KeyPaste, Text: "hello\rworld"},
+		{Key: KeyRune, Rune: 'a'},
+		{Key: KeyFocusOut},
+	}, decodeString("\x1b[I\x1b[200~hello\rworld\x1b[201~a\x1b[O", false))
+	assert.Equal(t, []Event(nil), decodeString("\x1b[200~hel", false))
+	assert.Equal(t, []Event{
+		{Key: KeyPaste, Text: "hel"},
+	}, decodeString("\x1b[200~hel", true))
+}
@@ -0,0 +1,110 @@
+package input
+
+import (
+	"image"
+)
+
+// Button identifies a mouse button or scroll wheel direction.
+type Button int
+
+const (
+	// ButtonNone indicates motion without a button held, or a release in the
+	// X10 encoding, which does not say which button was released.
+	ButtonNone Button = iota
+	ButtonLeft
+	ButtonMiddle
+	ButtonRight
+	WheelUp
+	WheelDown
+	WheelLeft
+	WheelRight
+)
+
+// MouseAction distinguishes the kinds of mouse events.
+type MouseAction int
+
+const (
+	// MousePress indicates a button press, as for a click.
+	MousePress MouseAction = iota
+	// MouseRelease indicates a button release.
+	MouseRelease
+	// MouseDrag indicates motion while a button is held.
+	MouseDrag
+	// MouseMotion indicates motion without a button held.
+	MouseMotion
+	// MouseWheel indicates a turn of the scroll wheel.
+	MouseWheel
+)
+
+// Mouse describes a mouse event.
+type Mouse struct {
+	Action MouseAction
+	Button Button
+	// Point is the cell under the mouse, with the origin at the top left
+	// corner of the terminal, the same as display coordinates for a display
+	// that covers the whole terminal.
+	Point image.Point
+}
+
+// decodeMouseSGR decodes the SGR (1006) mouse encoding, "\x1b[<b;x;yM" for
+// presses and motion and "\x1b[<b;x;ym" for releases.
+func decodeMouseSGR(final byte, params []int) Event {
+	if len(params) < 3 || (final != 'M' && final != 'm') {
+		return Event{}
+	}
+	ev := mouseEvent(params[0], params[1], params[2])
+	if final == 'm' {
+		ev.Mouse.Action = MouseRelease
+	}
+	return ev
+}
+
+// decodeMouseX10 decodes the X10 mouse encoding, "\x1b[M" followed by three
+// bytes for the button and coordinates, each offset by 32.
+func decodeMouseX10(buf []byte, final bool) (Event, int) {
+	if len(buf) < 6 {
+		if !final {
+			return Event{}, 0
+		}
+		return Event{}, len(buf)
+	}
+	return mouseEvent(int(buf[3])-32, int(buf[4])-32, int(buf[5])-32), 6
+}
+
+// mouseEvent interprets the button code and one-based coordinates common to
+// both mouse encodings.
+func mouseEvent(b, x, y int) Event {
+	ev := Event{
+		Key: KeyMouse,
+		Mouse: Mouse{
+			Point: image.Pt(x-1, y-1),
+		},
+	}
+	if b&4 != 0 {
+		ev.Mod |= Shift
+	}
+	if b&8 != 0 {
+		ev.Mod |= Alt
+	}
+	if b&16 != 0 {
+		ev.Mod |= Ctrl
+	}
+	button := b & 3
+	switch {
+	case b&64 != 0:
+		ev.Mouse.Action = MouseWheel
+		ev.Mouse.Button = WheelUp + Button(button)
+	case b&32 != 0 && button == 3:
+		ev.Mouse.Action = MouseMotion
+	case b&32 != 0:
+		ev.Mouse.Action = MouseDrag
+		ev.Mouse.Button = ButtonLeft + Button(button)
+	case button == 3:
+		// The X10 encoding reports every release as button 3.
+		ev.Mouse.Action = MouseRelease
+	default:
+		ev.Mouse.Action = MousePress
+		ev.Mouse.Button = ButtonLeft + Button(button)
+	}
+	return ev
+}
@@ -0,0 +1,206 @@
+// Package mux multiplexes programs running on pseudo-terminals into panes of
+// a single display, like a minimal tmux.
+//
+// Each pane has its own pseudo-terminal, a vtio display writer that
+// interprets the program's output, and a rectangle of the host's display.
+// The multiplexer draws every pane onto the host's display, routes input to
+// the focused pane, and tells programs when their panes change size.
+package mux
+
+import (
+	"image"
+	"io"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/terminal"
+	"github.com/kriskowal/cops/vtio"
+	"github.com/pkg/term/termios"
+)
+
+// Mux is a set of panes, one of which may have focus.
+type Mux struct {
+	lock  sync.RWMutex
+	panes []*Pane
+	focus *Pane
+	c     chan struct{}
+}
+
+// New returns an empty multiplexer.
+func New() *Mux {
+	return &Mux{c: make(chan struct{}, 1)}
+}
+
+// C returns a channel that receives whenever the content of a pane changes
+// or a program exits, coalescing changes that arrive before the host draws.
+//
+//	for range m.C() {
+//		m.Draw(front)
+//		buf, cur = display.RenderOver(buf, cur, front, back, display.Model24)
+//		...
+//	}
+func (m *Mux) C() <-chan struct{} {
+	return m.c
+}
+
+func (m *Mux) notify() {
+	select {
+	case m.c <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs a command in a new pane occupying a rectangle of the host's
+// display.
+// The command's standard input, output, and error are a new
+// pseudo-terminal the size of the rectangle, which becomes its controlling
+// terminal unless the command already has process attributes.
+// The first pane receives focus.
+func (m *Mux) Start(cmd *exec.Cmd, rect image.Rectangle) (*Pane, error) {
+	leader, follower, err := termios.Pty()
+	if err != nil {
+		return nil, err
+	}
+	defer follower.Close()
+
+	if err := terminal.SetSize(follower.Fd(), rect.Size()); err != nil {
+		leader.Close()
+		return nil, err
+	}
+
+	cmd.Stdin = follower
+	cmd.Stdout = follower
+	cmd.Stderr = follower
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	}
+	if err := cmd.Start(); err != nil {
+		leader.Close()
+		return nil, err
+	}
+
+	p := m.add(leader, rect, func(size image.Point) error {
+		return terminal.SetSize(leader.Fd(), size)
+	})
+	go p.run(cmd.Wait)
+	return p, nil
+}
+
+// add adds a pane for a program that reads and writes through a
+// pseudo-terminal leader.
+func (m *Mux) add(leader io.ReadWriteCloser, rect image.Rectangle, setSize func(image.Point) error) *Pane {
+	p := &Pane{
+		mux:     m,
+		leader:  leader,
+		setSize: setSize,
+		rect:    rect,
+		vtw:     vtio.NewDisplayWriter(image.Rectangle{Max: rect.Size()}),
+		done:    make(chan struct{}),
+	}
+	p.vtw.SetResponseWriter(leader)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.panes = append(m.panes, p)
+	if m.focus == nil {
+		m.focus = p
+	}
+	return p
+}
+
+// remove removes a pane, passing focus to the next pane if it had focus.
+func (m *Mux) remove(p *Pane) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i, q := range m.panes {
+		if q != p {
+			continue
+		}
+		m.panes = append(m.panes[:i], m.panes[i+1:]...)
+		if m.focus == p {
+			m.focus = nil
+			if len(m.panes) > 0 {
+				m.focus = m.panes[i%len(m.panes)]
+			}
+		}
+		break
+	}
+	m.notify()
+}
+
+// Panes returns the panes, in the order the multiplexer draws them.
+func (m *Mux) Panes() []*Pane {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return append([]*Pane(nil), m.panes...)
+}
+
+// Focus gives a pane focus, so it receives input.
+func (m *Mux) Focus(p *Pane) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.focus = p
+	m.notify()
+}
+
+// Focused returns the pane with focus, or nil if there are no panes.
+func (m *Mux) Focused() *Pane {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.focus
+}
+
+// FocusNext passes focus to the next pane, in order, wrapping around.
+func (m *Mux) FocusNext() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i, p := range m.panes {
+		if p == m.focus {
+			m.focus = m.panes[(i+1)%len(m.panes)]
+			break
+		}
+	}
+	m.notify()
+}
+
+// Write sends input, like raw bytes read from the host's terminal, to the
+// program of the focused pane.
+// Without a focused pane, the input goes nowhere.
+func (m *Mux) Write(buf []byte) (int, error) {
+	p := m.Focused()
+	if p == nil {
+		return len(buf), nil
+	}
+	return p.Write(buf)
+}
+
+// Draw draws every pane onto the host's display, in order, so later panes
+// cover earlier panes where they overlap.
+func (m *Mux) Draw(d *display.Display) {
+	for _, p := range m.Panes() {
+		p.Draw(d)
+	}
+}
+
+// Cursor returns the cursor of the focused pane in the coordinates of the
+// host's display, and false if there is no focused pane.
+func (m *Mux) Cursor() (vtio.Cursor, bool) {
+	p := m.Focused()
+	if p == nil {
+		return vtio.Cursor{}, false
+	}
+	return p.Cursor(), true
+}
+
+// Close closes every pane.
+func (m *Mux) Close() error {
+	var err error
+	for _, p := range m.Panes() {
+		if e := p.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
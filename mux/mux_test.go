@@ -0,0 +1,122 @@
+package mux
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePty stands in for the leader of a pseudo-terminal, carrying output
+// from the test to the pane, and collecting the pane's input.
+type fakePty struct {
+	*io.PipeReader
+	output *io.PipeWriter
+	lock   sync.Mutex
+	input  bytes.Buffer
+	sizes  []image.Point
+}
+
+func (f *fakePty) Write(buf []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.input.Write(buf)
+}
+
+func (f *fakePty) Input() string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.input.String()
+}
+
+func (f *fakePty) setSize(size image.Point) error {
+	f.sizes = append(f.sizes, size)
+	return nil
+}
+
+func start(m *Mux, rect image.Rectangle) (*Pane, *fakePty) {
+	r, w := io.Pipe()
+	f := &fakePty{PipeReader: r, output: w}
+	p := m.add(f, rect, f.setSize)
+	go p.run(func() error { return nil })
+	return p, f
+}
+
+func text(d *display.Display) string {
+	var s string
+	for _, t := range d.Text.Strings {
+		if t == "" {
+			t = "."
+		}
+		s += t
+	}
+	return s
+}
+
+func TestDraw(t *testing.T) {
+	m := New()
+	_, left := start(m, image.Rect(0, 0, 2, 1))
+	_, right := start(m, image.Rect(2, 0, 4, 1))
+	left.output.Write([]byte("ab"))
+	right.output.Write([]byte("\033[2Gc"))
+
+	d := display.New(image.Rect(0, 0, 4, 1))
+	assert.Eventually(t, func() bool {
+		m.Draw(d)
+		return text(d) == "ab.c"
+	}, time.Second, time.Millisecond)
+}
+
+func TestFocus(t *testing.T) {
+	m := New()
+	first, a := start(m, image.Rect(0, 0, 2, 1))
+	second, b := start(m, image.Rect(2, 0, 4, 1))
+	assert.Equal(t, first, m.Focused())
+
+	m.Write([]byte("x"))
+	m.FocusNext()
+	assert.Equal(t, second, m.Focused())
+	m.Write([]byte("y"))
+	assert.Equal(t, "x", a.Input())
+	assert.Equal(t, "y", b.Input())
+
+	c, ok := m.Cursor()
+	assert.True(t, ok)
+	assert.Equal(t, image.Pt(2, 0), c.Position)
+
+	// Closing the focused pane passes focus on.
+	second.Close()
+	assert.Equal(t, first, m.Focused())
+	assert.Equal(t, []*Pane{first}, m.Panes())
+	first.Close()
+	assert.Nil(t, m.Focused())
+	_, ok = m.Cursor()
+	assert.False(t, ok)
+}
+
+func TestResize(t *testing.T) {
+	m := New()
+	p, f := start(m, image.Rect(0, 0, 2, 1))
+	assert.NoError(t, p.Resize(image.Rect(1, 1, 3, 2)))
+	assert.Nil(t, f.sizes)
+	assert.NoError(t, p.Resize(image.Rect(0, 0, 4, 2)))
+	assert.Equal(t, []image.Point{{4, 2}}, f.sizes)
+	assert.Equal(t, image.Rect(0, 0, 4, 2), p.Rect())
+}
+
+func TestDone(t *testing.T) {
+	m := New()
+	p, f := start(m, image.Rect(0, 0, 2, 1))
+	f.output.Close()
+	select {
+	case <-p.Done():
+	case <-time.After(time.Second):
+		t.Fatal("pane not done")
+	}
+	assert.NoError(t, p.Err())
+}
@@ -0,0 +1,110 @@
+package mux
+
+import (
+	"image"
+	"io"
+	"sync"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/vtio"
+)
+
+// Pane is a program running on a pseudo-terminal, shown in a rectangle of
+// the host's display.
+type Pane struct {
+	mux     *Mux
+	leader  io.ReadWriteCloser
+	setSize func(image.Point) error
+	vtw     *vtio.DisplayWriter
+	done    chan struct{}
+	err     error
+
+	lock sync.RWMutex
+	rect image.Rectangle
+}
+
+// run interprets the program's output until the pseudo-terminal closes, then
+// waits for the program to exit.
+func (p *Pane) run(wait func() error) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := p.leader.Read(buf)
+		if n > 0 {
+			p.vtw.Write(buf[:n])
+			p.mux.notify()
+		}
+		if err != nil {
+			break
+		}
+	}
+	p.err = wait()
+	close(p.done)
+	p.mux.notify()
+}
+
+// Rect returns the rectangle of the host's display that the pane occupies.
+func (p *Pane) Rect() image.Rectangle {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.rect
+}
+
+// Resize moves the pane to another rectangle of the host's display.
+// If the size changes, the pane's display writer reflows and the program
+// receives the new size of its terminal, with SIGWINCH, so it can redraw.
+func (p *Pane) Resize(rect image.Rectangle) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	size := rect.Size()
+	resized := size != p.rect.Size()
+	p.rect = rect
+	defer p.mux.notify()
+	if !resized {
+		return nil
+	}
+	p.vtw.Resize(image.Rectangle{Max: size})
+	return p.setSize(size)
+}
+
+// DisplayWriter returns the display writer that interprets the program's
+// output, for its scrollback.
+func (p *Pane) DisplayWriter() *vtio.DisplayWriter {
+	return p.vtw
+}
+
+// Write sends input to the program.
+func (p *Pane) Write(buf []byte) (int, error) {
+	return p.leader.Write(buf)
+}
+
+// Draw draws the pane onto the host's display, in its rectangle.
+func (p *Pane) Draw(d *display.Display) {
+	p.vtw.Draw(d, p.Rect())
+}
+
+// Cursor returns the program's cursor in the coordinates of the host's
+// display.
+func (p *Pane) Cursor() vtio.Cursor {
+	c := p.vtw.Cursor()
+	c.Position = c.Position.Add(p.Rect().Min)
+	return c
+}
+
+// Done returns a channel that closes when the program exits.
+// The pane remains, showing the program's last output, until closed.
+func (p *Pane) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns the error with which the program exited, once Done closes.
+func (p *Pane) Err() error {
+	<-p.done
+	return p.err
+}
+
+// Close removes the pane from its multiplexer and closes its
+// pseudo-terminal, which hangs up the program if it is still running.
+func (p *Pane) Close() error {
+	p.mux.remove(p)
+	return p.leader.Close()
+}
@@ -0,0 +1,158 @@
+package screen
+
+import (
+	"image"
+	"io"
+
+	"github.com/kriskowal/cops/display"
+)
+
+// Inline renders a display in rows beneath the terminal's cursor, instead of
+// taking over the whole screen, as for a picker or a live status block below
+// a shell prompt.
+// Inline moves the cursor only relative to its position, so it renders
+// wherever the cursor happens to be, scrolling the terminal to make room if
+// necessary.
+//
+// Nothing else may write to the terminal until the inline display closes or
+// erases, and the display must be no taller than the terminal.
+//
+//	in := screen.NewInline(os.Stdout, image.Pt(width, 3))
+//	defer in.Close()
+//	for {
+//		// draw on in.Display() ...
+//		if err := in.Flush(); err != nil {
+//			return err
+//		}
+//	}
+type Inline struct {
+	// Model is the color model to render in, Model24 by default.
+	Model display.Model
+
+	w           io.Writer
+	front, back *display.Display
+	buf         []byte
+	cur         display.Cursor
+	// rows is the number of rows the inline display has reserved.
+	rows int
+	done bool
+}
+
+// NewInline returns an inline display of the given size that renders,
+// to the given writer, from the beginning of the row with the cursor.
+// The row should be empty, so begin a line before rendering inline.
+// The first Flush reserves the rows.
+func NewInline(w io.Writer, size image.Point) *Inline {
+	// The cursor is somewhere on the row of the origin.
+	cur := display.Start
+	cur.Position = image.Pt(-1, 0)
+	return &Inline{
+		Model: display.Model24,
+		w:     w,
+		front: display.New(image.Rectangle{Max: size}),
+		cur:   cur,
+	}
+}
+
+// Display returns the display to draw the next frame on.
+// The display holds the last frame flushed, so a frame need only draw what
+// changes.
+// Resize replaces the display.
+func (in *Inline) Display() *display.Display {
+	return in.front
+}
+
+// Bounds returns the bounds of the inline display, at the origin.
+func (in *Inline) Bounds() image.Rectangle {
+	return in.front.Rect
+}
+
+// Flush renders the changes to the display since the last frame, the first
+// time reserving its rows beneath the cursor and hiding the cursor.
+func (in *Inline) Flush() error {
+	buf, cur := in.buf, in.cur
+	if in.back == nil {
+		buf, cur = in.reserve(buf, cur)
+	}
+	buf, cur = display.RenderInline(buf, cur, in.front, in.back, in.Model)
+	in.cur = cur
+	in.buf = buf[0:0]
+
+	if in.back == nil {
+		in.back = display.New(in.front.Rect)
+	}
+	in.front, in.back = in.back, in.front
+	copyDisplay(in.front, in.back)
+
+	_, err := in.w.Write(buf)
+	return err
+}
+
+// reserve appends the commands that make room for the display, erasing from
+// its origin to the end of the screen and scrolling the terminal if
+// necessary, and returns the cursor at the display's origin.
+// The terminal then shows nothing in the display's rows, as a nil back
+// display models.
+func (in *Inline) reserve(buf []byte, cur display.Cursor) ([]byte, display.Cursor) {
+	if in.rows == 0 {
+		buf, cur = cur.Hide(buf)
+	}
+	buf, cur = cur.Reset(buf)
+	buf, cur = cur.Go(buf, image.ZP)
+	buf = append(buf, "\033[J"...)
+	in.rows = in.front.Rect.Dy()
+	if in.rows > 1 {
+		// Advancing through the rows scrolls the terminal when they do not
+		// fit.
+		buf, cur = cur.Go(buf, image.Pt(0, in.rows-1))
+		buf, cur = cur.Go(buf, image.ZP)
+	}
+	return buf, cur
+}
+
+// Resize replaces the display with a blank display of a new size, repainting
+// every row on the next Flush and erasing any rows left over.
+// The width should not exceed the terminal's.
+func (in *Inline) Resize(size image.Point) {
+	in.front = display.New(image.Rectangle{Max: size})
+	in.back = nil
+}
+
+// Close leaves the last frame in the terminal's scrollback, moving the cursor
+// to the beginning of the row beneath it, and shows the cursor again.
+// Close is safe to call more than once, or after Erase.
+func (in *Inline) Close() error {
+	if in.done {
+		return nil
+	}
+	in.done = true
+	buf, cur := in.buf, in.cur
+	if in.rows > 0 {
+		buf, cur = cur.Reset(buf)
+		buf, cur = cur.Go(buf, image.Pt(0, in.rows))
+		buf, cur = cur.Show(buf)
+	}
+	in.buf = buf[0:0]
+	_, err := in.w.Write(buf)
+	return err
+}
+
+// Erase erases the rows of the inline display, leaving the cursor at the
+// beginning of the row where the display began, and shows the cursor again.
+// Erase is safe to call more than once, or after Close.
+func (in *Inline) Erase() error {
+	if in.done {
+		return nil
+	}
+	in.done = true
+	buf, cur := in.buf, in.cur
+	if in.rows > 0 {
+		buf, cur = cur.Reset(buf)
+		buf, cur = cur.Go(buf, image.ZP)
+		buf = append(buf, "\033[J"...)
+		buf, cur = cur.Show(buf)
+	}
+	in.buf = buf[0:0]
+	_, err := in.w.Write(buf)
+	return err
+}
@@ -0,0 +1,110 @@
+package screen
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/displaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+// rows returns the text of each row of a terminal's screen, trimmed.
+func rows(term *displaytest.Terminal) []string {
+	d := term.Display()
+	var rows []string
+	for y := d.Rect.Min.Y; y < d.Rect.Max.Y; y++ {
+		var row string
+		for x := d.Rect.Min.X; x < d.Rect.Max.X; x++ {
+			t, _ := d.GlyphAt(x, y)
+			row += t
+		}
+		rows = append(rows, strings.TrimRight(row, " "))
+	}
+	return rows
+}
+
+func put(d *display.Display, y int, s string) {
+	for x, r := range s {
+		d.Set(x, y, string(r), display.Colors[7], display.Colors[0])
+	}
+}
+
+// prompt returns a terminal with a shell session scrolled to the bottom, and
+// the cursor at the beginning of the last row.
+func prompt() *displaytest.Terminal {
+	term := displaytest.NewTerminal(image.Rect(0, 0, 6, 4))
+	term.Write([]byte("$ a\r\n$ b\r\n$ c\r\n"))
+	return term
+}
+
+// writer records whether any output moves the cursor to an absolute position.
+type writer struct {
+	*displaytest.Terminal
+	absolute bool
+}
+
+func (w *writer) Write(buf []byte) (int, error) {
+	if strings.Contains(string(buf), "H") {
+		w.absolute = true
+	}
+	return w.Terminal.Write(buf)
+}
+
+func TestInline(t *testing.T) {
+	w := &writer{Terminal: prompt()}
+	in := NewInline(w, image.Pt(6, 2))
+	put(in.Display(), 0, "hello!")
+	put(in.Display(), 1, "world!")
+	assert.NoError(t, in.Flush())
+	assert.Equal(t, []string{"$ b", "$ c", "hello!", "world!"}, rows(w.Terminal))
+
+	put(in.Display(), 1, "there")
+	assert.NoError(t, in.Flush())
+	assert.Equal(t, []string{"$ b", "$ c", "hello!", "there!"}, rows(w.Terminal))
+
+	// The last frame remains, and the shell continues beneath it.
+	assert.NoError(t, in.Close())
+	assert.NoError(t, in.Close())
+	w.Write([]byte("$ d"))
+	assert.Equal(t, []string{"$ c", "hello!", "there!", "$ d"}, rows(w.Terminal))
+	assert.False(t, w.absolute)
+}
+
+func TestInlineErase(t *testing.T) {
+	term := prompt()
+	in := NewInline(term, image.Pt(6, 2))
+	put(in.Display(), 0, "hello")
+	put(in.Display(), 1, "world")
+	assert.NoError(t, in.Flush())
+
+	assert.NoError(t, in.Erase())
+	term.Write([]byte("$ d"))
+	assert.Equal(t, []string{"$ b", "$ c", "$ d", ""}, rows(term))
+}
+
+func TestInlineResize(t *testing.T) {
+	term := prompt()
+	in := NewInline(term, image.Pt(6, 2))
+	put(in.Display(), 0, "hello")
+	put(in.Display(), 1, "world")
+	assert.NoError(t, in.Flush())
+
+	in.Resize(image.Pt(6, 1))
+	put(in.Display(), 0, "done")
+	assert.NoError(t, in.Flush())
+	assert.Equal(t, []string{"$ b", "$ c", "done", ""}, rows(term))
+
+	assert.NoError(t, in.Close())
+	term.Write([]byte("$ d"))
+	assert.Equal(t, []string{"$ b", "$ c", "done", "$ d"}, rows(term))
+}
+
+func TestInlineCloseUnflushed(t *testing.T) {
+	term := prompt()
+	in := NewInline(term, image.Pt(6, 2))
+	assert.NoError(t, in.Close())
+	term.Write([]byte("$ d"))
+	assert.Equal(t, []string{"$ a", "$ b", "$ c", "$ d"}, rows(term))
+}
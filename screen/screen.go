@@ -0,0 +1,256 @@
+// Package screen runs a full-screen application on a terminal, owning the
+// front and back displays, the cursor, and the bytes that carry each frame to
+// the terminal.
+// An Inline screen renders beneath the shell prompt instead.
+//
+// Draw on the screen's display, then Flush to render the changes since the
+// last frame.
+//
+//	s, err := screen.New(terminal.New(os.Stdin.Fd()), os.Stdout)
+//	if err != nil {
+//		return err
+//	}
+//	defer s.Close()
+//	for {
+//		d := s.Display()
+//		// draw on d ...
+//		if err := s.Flush(); err != nil {
+//			return err
+//		}
+//	}
+package screen
+
+import (
+	"image"
+	"io"
+	"sync"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/terminal"
+)
+
+// Screen is a terminal in a full-screen session, with a display to draw on
+// and a model of what the terminal shows, to render only what changes.
+// The screen follows the terminal's size, taking a new size the next time the
+// application asks for its display or bounds or flushes, and signals Resized
+// so the application can draw the next frame to fit.
+type Screen struct {
+	// Model is the color model to render in, Model24 by default.
+	Model display.Model
+	// Render renders the front display over the back display, RenderOver by
+	// default.
+	// RenderScrolling suits displays whose rows scroll, like logs.
+	Render func(buf []byte, cur display.Cursor, over, under *display.Display, model display.Model) ([]byte, display.Cursor)
+	// Synchronized wraps each frame in a synchronized update, for terminals
+	// that support terminal.SynchronizedOutput, so large frames do not tear.
+	Synchronized bool
+
+	w           io.Writer
+	session     io.Closer
+	front, back *display.Display
+	buf         []byte
+	cur         display.Cursor
+
+	// The cursor the application wants shown, and the cursor the terminal
+	// shows.
+	visible, shown bool
+	position       image.Point
+	shape, shaped  display.CursorShape
+
+	// The terminal's latest bounds, if the screen has yet to take them, from
+	// the goroutine that follows the terminal's size.
+	lock    sync.Mutex
+	bounds  image.Rectangle
+	resize  bool
+	resized chan struct{}
+	stop    func()
+}
+
+// New starts a full-screen session on a terminal, writing to the given
+// writer, typically standard output, and returns a screen the size of the
+// terminal.
+//
+// Defer Close immediately, so the terminal is restored even if the program
+// panics.
+func New(term terminal.Terminal, w io.Writer) (*Screen, error) {
+	bounds, err := term.Bounds()
+	if err != nil {
+		return nil, err
+	}
+	session, err := terminal.NewSession(term, w)
+	if err != nil {
+		return nil, err
+	}
+	s := newScreen(w, session, bounds)
+	s.follow(term.Resizes())
+	return s, nil
+}
+
+func newScreen(w io.Writer, session io.Closer, bounds image.Rectangle) *Screen {
+	front, back := display.New2(bounds)
+	return &Screen{
+		Model:   display.Model24,
+		Render:  display.RenderOver,
+		w:       w,
+		session: session,
+		front:   front,
+		back:    back,
+		cur:     display.Start,
+		resized: make(chan struct{}, 1),
+		stop:    func() {},
+	}
+}
+
+// follow takes the bounds from a channel of the terminal's sizes, until
+// stopped.
+func (s *Screen) follow(resizes <-chan image.Rectangle, stop func()) {
+	s.stop = stop
+	go func() {
+		for bounds := range resizes {
+			s.lock.Lock()
+			s.bounds, s.resize = bounds, true
+			s.lock.Unlock()
+			select {
+			case s.resized <- struct{}{}:
+			default:
+			}
+		}
+	}()
+}
+
+// take resizes the screen if the terminal has changed size.
+func (s *Screen) take() {
+	s.lock.Lock()
+	bounds, resize := s.bounds, s.resize
+	s.resize = false
+	s.lock.Unlock()
+	if resize && bounds != s.front.Rect {
+		s.Resize(bounds)
+	}
+}
+
+// Resized returns a channel that signals when the terminal changes size.
+// The screen takes the new size the next time the application calls Display,
+// Bounds, or Flush, so the application should draw a new frame.
+//
+//	for {
+//		select {
+//		case <-s.Resized():
+//			// draw on s.Display() ...
+//		case ev := <-events:
+//			// ...
+//		}
+//		if err := s.Flush(); err != nil {
+//			return err
+//		}
+//	}
+func (s *Screen) Resized() <-chan struct{} {
+	return s.resized
+}
+
+// Display returns the display to draw the next frame on.
+// The display holds the last frame flushed, so a frame need only draw what
+// changes.
+// A resize replaces the display.
+func (s *Screen) Display() *display.Display {
+	s.take()
+	return s.front
+}
+
+// Bounds returns the bounds of the screen.
+func (s *Screen) Bounds() image.Rectangle {
+	s.take()
+	return s.front.Rect
+}
+
+// ShowCursor shows the terminal's cursor at a position, in a shape, after the
+// next Flush.
+// The cursor is hidden until the application shows it.
+func (s *Screen) ShowCursor(position image.Point, shape display.CursorShape) {
+	s.visible, s.position, s.shape = true, position, shape
+}
+
+// HideCursor hides the terminal's cursor after the next Flush.
+func (s *Screen) HideCursor() {
+	s.visible = false
+}
+
+// Flush renders the changes to the display since the last frame to the
+// terminal, and places the cursor.
+// Flush reuses its buffer from frame to frame.
+// If the terminal has changed size since the application last asked for the
+// display, Flush clears the terminal instead of rendering the frame drawn on
+// the old display.
+func (s *Screen) Flush() error {
+	s.take()
+	buf, cur := s.buf, s.cur
+	if s.Synchronized {
+		buf, cur = cur.BeginSynchronizedUpdate(buf)
+	}
+	buf, cur = s.Render(buf, cur, s.front, s.back, s.Model)
+	if s.visible {
+		if cur.Position.X >= s.front.Rect.Max.X {
+			// Having rendered the last column, the terminal may hold the
+			// cursor there until the next glyph, so only the row is certain.
+			cur.Position.X = -1
+		}
+		buf, cur = cur.Go(buf, s.position)
+		if s.shape != s.shaped {
+			buf, cur = cur.SetShape(buf, s.shape)
+			s.shaped = s.shape
+		}
+		if !s.shown {
+			buf, cur = cur.Show(buf)
+			s.shown = true
+		}
+	} else if s.shown {
+		buf, cur = cur.Hide(buf)
+		s.shown = false
+	}
+	if s.Synchronized {
+		buf, cur = cur.EndSynchronizedUpdate(buf)
+	}
+	s.cur = cur
+	s.buf = buf[0:0]
+
+	// The back display now models the terminal, and the front display keeps
+	// the frame to draw the next over.
+	s.front, s.back = s.back, s.front
+	copyDisplay(s.front, s.back)
+
+	_, err := s.w.Write(buf)
+	return err
+}
+
+// Resize replaces the displays with blank displays of new bounds, and clears
+// the terminal on the next Flush.
+// The screen resizes itself when the terminal changes size.
+func (s *Screen) Resize(bounds image.Rectangle) {
+	s.buf, s.cur, s.front, s.back = display.Resize(s.buf, s.cur, bounds)
+}
+
+// Close stops following the terminal's size and restores the terminal.
+// Close is safe to call more than once.
+func (s *Screen) Close() error {
+	s.stop()
+	return s.session.Close()
+}
+
+// Recover restores the terminal if the calling goroutine is panicking, then
+// continues panicking.
+// Defer Recover at the top of every goroutine that shares the terminal, as
+// with terminal.Session.
+func (s *Screen) Recover() {
+	if r := recover(); r != nil {
+		s.Close()
+		panic(r)
+	}
+}
+
+// copyDisplay copies every cell of a display onto another of the same bounds.
+func copyDisplay(dst, src *display.Display) {
+	copy(dst.Background.Pix, src.Background.Pix)
+	copy(dst.Foreground.Pix, src.Foreground.Pix)
+	copy(dst.Text.Strings, src.Text.Strings)
+	copy(dst.Attributes.Attrs, src.Attributes.Attrs)
+}
@@ -0,0 +1,158 @@
+package screen
+
+import (
+	"bytes"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/displaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+type session struct {
+	closed int
+}
+
+func (s *session) Close() error {
+	s.closed++
+	return nil
+}
+
+func TestFlush(t *testing.T) {
+	var out bytes.Buffer
+	s := newScreen(&out, &session{}, image.Rect(0, 0, 4, 1))
+	s.Model = display.Model0
+
+	s.Display().Set(1, 0, "a", display.Colors[7], display.Colors[0])
+	assert.NoError(t, s.Flush())
+	assert.Contains(t, out.String(), "a")
+
+	// The display keeps the last frame, so only the new cell renders.
+	out.Reset()
+	s.Display().Set(2, 0, "b", display.Colors[7], display.Colors[0])
+	assert.NoError(t, s.Flush())
+	assert.Equal(t, "b", out.String())
+	assert.Equal(t, "a", s.Display().Text.At(1, 0))
+
+	// Nothing changed.
+	out.Reset()
+	assert.NoError(t, s.Flush())
+	assert.Equal(t, "", out.String())
+}
+
+// frames records the first byte of each frame written, to see whether frames
+// share memory.
+type frames []*byte
+
+func (f *frames) Write(buf []byte) (int, error) {
+	*f = append(*f, &buf[0])
+	return len(buf), nil
+}
+
+func TestFlushReusesBuffer(t *testing.T) {
+	var f frames
+	s := newScreen(&f, &session{}, image.Rect(0, 0, 8, 2))
+	d := s.Display()
+	d.Fill(d.Rect, "x", display.Colors[7], display.Colors[0])
+	assert.NoError(t, s.Flush())
+
+	d = s.Display()
+	d.Text.Set(3, 1, "y")
+	assert.NoError(t, s.Flush())
+	assert.Len(t, f, 2)
+	assert.True(t, f[0] == f[1])
+}
+
+func TestCursor(t *testing.T) {
+	var out bytes.Buffer
+	s := newScreen(&out, &session{}, image.Rect(0, 0, 4, 2))
+
+	s.ShowCursor(image.Pt(1, 1), display.CursorBar)
+	assert.NoError(t, s.Flush())
+	assert.Equal(t, "\033[2;2H\033[6 q\033[?25h", out.String())
+
+	// The terminal already shows the cursor in that shape.
+	out.Reset()
+	s.ShowCursor(image.Pt(2, 1), display.CursorBar)
+	assert.NoError(t, s.Flush())
+	assert.Equal(t, "\033[1C", out.String())
+
+	out.Reset()
+	s.HideCursor()
+	assert.NoError(t, s.Flush())
+	assert.Equal(t, "\033[?25l", out.String())
+}
+
+func TestCursorAfterLastColumn(t *testing.T) {
+	bounds := image.Rect(0, 0, 5, 2)
+	term := displaytest.NewTerminal(bounds)
+	s := newScreen(term, &session{}, bounds)
+	s.Model = display.Model0
+
+	s.Display().Set(4, 1, "z", display.Colors[7], display.Colors[0])
+	s.ShowCursor(image.Pt(1, 0), display.CursorDefault)
+	assert.NoError(t, s.Flush())
+	assert.Equal(t, image.Pt(1, 0), term.Cursor())
+}
+
+func TestSynchronized(t *testing.T) {
+	var out bytes.Buffer
+	s := newScreen(&out, &session{}, image.Rect(0, 0, 4, 1))
+	s.Synchronized = true
+	assert.NoError(t, s.Flush())
+	assert.Equal(t, "\033[?2026h\033[?2026l", out.String())
+}
+
+func TestResize(t *testing.T) {
+	var out bytes.Buffer
+	s := newScreen(&out, &session{}, image.Rect(0, 0, 4, 1))
+	s.Display().Set(0, 0, "a", display.Colors[7], display.Colors[0])
+	assert.NoError(t, s.Flush())
+
+	out.Reset()
+	s.Resize(image.Rect(0, 0, 6, 2))
+	assert.Equal(t, image.Rect(0, 0, 6, 2), s.Bounds())
+	assert.Equal(t, "", s.Display().Text.At(0, 0))
+	assert.NoError(t, s.Flush())
+	assert.Contains(t, out.String(), "\033[2J")
+}
+
+func TestFollowResizes(t *testing.T) {
+	var out bytes.Buffer
+	c := &session{}
+	s := newScreen(&out, c, image.Rect(0, 0, 4, 1))
+	resizes := make(chan image.Rectangle, 1)
+	stopped := 0
+	s.follow(resizes, func() {
+		stopped++
+		close(resizes)
+	})
+	s.Display().Set(0, 0, "a", display.Colors[7], display.Colors[0])
+	assert.NoError(t, s.Flush())
+
+	out.Reset()
+	resizes <- image.Rect(0, 0, 6, 2)
+	select {
+	case <-s.Resized():
+	case <-time.After(time.Second):
+		t.Fatal("not resized")
+	}
+	assert.Equal(t, image.Rect(0, 0, 6, 2), s.Bounds())
+	assert.Equal(t, "", s.Display().Text.At(0, 0))
+	assert.NoError(t, s.Flush())
+	assert.Contains(t, out.String(), "\033[2J")
+
+	assert.NoError(t, s.Close())
+	assert.Equal(t, 1, stopped)
+	assert.Equal(t, 1, c.closed)
+}
+
+func TestClose(t *testing.T) {
+	var out bytes.Buffer
+	c := &session{}
+	s := newScreen(&out, c, image.Rect(0, 0, 4, 1))
+	assert.NoError(t, s.Close())
+	assert.Equal(t, 1, c.closed)
+}
@@ -0,0 +1,101 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+)
+
+// ModeStatus is a terminal's report of the status of a DEC private mode, in
+// answer to a DECRQM request.
+type ModeStatus int
+
+const (
+	// ModeUnknown indicates that the terminal does not recognize the mode,
+	// or does not answer DECRQM requests.
+	ModeUnknown ModeStatus = iota
+	ModeSet
+	ModeReset
+	ModePermanentlySet
+	ModePermanentlyReset
+)
+
+// Supported returns whether the terminal recognizes the mode and allows
+// setting it.
+func (s ModeStatus) Supported() bool {
+	return s == ModeSet || s == ModeReset || s == ModePermanentlySet
+}
+
+// SynchronizedOutput is the DEC private mode for synchronized updates, during
+// which the terminal holds the screen still, so a frame does not tear.
+// See the BeginSynchronizedUpdate method of the display Cursor.
+const SynchronizedOutput = 2026
+
+// QueryMode asks a terminal for the status of a DEC private mode, writing a
+// DECRQM request and reading the report.
+// The terminal must be in raw mode, as in a Session, and nothing else may
+// read from the terminal until QueryMode returns.
+//
+// QueryMode follows the request with a request for the terminal's primary
+// device attributes, which every terminal answers, so QueryMode does not wait
+// forever for a terminal that ignores DECRQM, but reports ModeUnknown.
+// QueryMode discards anything else the terminal sends, like keys pressed in
+// the meantime.
+//
+//	sync, err := terminal.QueryMode(os.Stdin, os.Stdout, terminal.SynchronizedOutput)
+func QueryMode(r io.Reader, w io.Writer, mode int) (ModeStatus, error) {
+	request := "\033[?" + strconv.Itoa(mode) + "$p\033[c"
+	if _, err := io.WriteString(w, request); err != nil {
+		return ModeUnknown, err
+	}
+	var buf []byte
+	var rbuf [64]byte
+	for {
+		n, err := r.Read(rbuf[:])
+		buf = append(buf, rbuf[:n]...)
+		if status, done := scanReports(buf, mode); done {
+			return status, nil
+		}
+		if err != nil {
+			return ModeUnknown, err
+		}
+	}
+}
+
+// scanReports scans a terminal's reports for the status of a mode, returning
+// it once the terminal reports its device attributes, which the terminal sends
+// last.
+func scanReports(buf []byte, mode int) (ModeStatus, bool) {
+	status := ModeUnknown
+	for {
+		i := bytes.Index(buf, []byte("\033["))
+		if i < 0 {
+			return status, false
+		}
+		buf = buf[i+2:]
+		// Parameters, intermediates, then a final byte.
+		j := 0
+		for j < len(buf) && buf[j] >= 0x20 && buf[j] < 0x40 {
+			j++
+		}
+		if j == len(buf) {
+			return status, false
+		}
+		seq, final := string(buf[:j]), buf[j]
+		buf = buf[j+1:]
+		switch {
+		case final == 'c' && len(seq) > 0 && seq[0] == '?':
+			return status, true
+		case final == 'y' && len(seq) > 0 && seq[0] == '?' && seq[len(seq)-1] == '$':
+			var m, s int
+			params := bytes.Split([]byte(seq[1:len(seq)-1]), []byte(";"))
+			if len(params) == 2 {
+				m, _ = strconv.Atoi(string(params[0]))
+				s, _ = strconv.Atoi(string(params[1]))
+			}
+			if m == mode && s >= 0 && s <= int(ModePermanentlyReset) {
+				status = ModeStatus(s)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryMode(t *testing.T) {
+	var w bytes.Buffer
+	r := strings.NewReader("x\033[?2026;2$y\033[?62;22c")
+	status, err := QueryMode(r, &w, SynchronizedOutput)
+	assert.NoError(t, err)
+	assert.Equal(t, ModeReset, status)
+	assert.True(t, status.Supported())
+	assert.Equal(t, "\033[?2026$p\033[c", w.String())
+}
+
+func TestQueryModeIgnored(t *testing.T) {
+	// Terminals that ignore DECRQM answer only the device attributes.
+	status, err := QueryMode(strings.NewReader("\033[?1;2c"), io.Discard, SynchronizedOutput)
+	assert.NoError(t, err)
+	assert.Equal(t, ModeUnknown, status)
+	assert.False(t, status.Supported())
+}
+
+func TestQueryModeOtherMode(t *testing.T) {
+	status, err := QueryMode(strings.NewReader("\033[?25;1$y\033[?2026;4$y\033[?1;2c"), io.Discard, SynchronizedOutput)
+	assert.NoError(t, err)
+	assert.Equal(t, ModePermanentlyReset, status)
+	assert.False(t, status.Supported())
+}
+
+func TestQueryModeSplit(t *testing.T) {
+	// The reports may arrive a byte at a time.
+	r := &byteReader{s: "\033[?2026;1$y\033[?1;2c"}
+	status, err := QueryMode(r, io.Discard, SynchronizedOutput)
+	assert.NoError(t, err)
+	assert.Equal(t, ModeSet, status)
+}
+
+func TestQueryModeEOF(t *testing.T) {
+	status, err := QueryMode(strings.NewReader("\033[?2026;1$y"), io.Discard, SynchronizedOutput)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, ModeUnknown, status)
+}
+
+type byteReader struct {
+	s string
+}
+
+func (r *byteReader) Read(buf []byte) (int, error) {
+	if r.s == "" {
+		return 0, io.EOF
+	}
+	buf[0] = r.s[0]
+	r.s = r.s[1:]
+	return 1, nil
+}
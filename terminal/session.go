@@ -0,0 +1,91 @@
+package terminal
+
+import (
+	"io"
+	"sync"
+
+	"github.com/kriskowal/cops/display"
+)
+
+// Session holds a terminal in the state most full-screen applications need:
+// raw mode, on the alternate screen so the user's scrollback survives, with
+// the cursor hidden and bracketed paste and focus reporting enabled.
+// Closing the session restores the terminal, including the cursor's shape, so
+// an application that changes the shape, like the screen package, need not
+// restore it.
+type Session struct {
+	term Terminal
+	w    io.Writer
+	once sync.Once
+	err  error
+}
+
+// NewSession prepares a terminal for a full-screen application, writing the
+// commands that alter the screen to the given writer, typically standard
+// output.
+//
+// Defer Close immediately, so the terminal is restored even if the program
+// panics.
+//
+//	term := terminal.New(os.Stdin.Fd())
+//	session, err := terminal.NewSession(term, os.Stdout)
+//	if err != nil {
+//		return err
+//	}
+//	defer session.Close()
+func NewSession(term Terminal, w io.Writer) (*Session, error) {
+	s := &Session{term: term, w: w}
+	term.SetRaw()
+
+	var buf []byte
+	cur := display.Start
+	buf, cur = cur.EnterAlternateScreen(buf)
+	buf, cur = cur.Hide(buf)
+	buf, cur = cur.EnableBracketedPaste(buf)
+	buf, cur = cur.EnableFocusReporting(buf)
+	if _, err := w.Write(buf); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close restores the terminal to the state it had before the session, leaving
+// the alternate screen and disabling every mode the session or the
+// application may have enabled, including mouse reporting, and restoring the
+// default cursor shape.
+// Close is safe to call more than once and returns the error from the first
+// call.
+func (s *Session) Close() error {
+	s.once.Do(func() {
+		var buf []byte
+		cur := display.Start
+		buf, cur = cur.DisableMouse(buf)
+		buf, cur = cur.DisableFocusReporting(buf)
+		buf, cur = cur.DisableBracketedPaste(buf)
+		buf, cur = cur.Reset(buf)
+		buf, cur = cur.SetShape(buf, display.CursorDefault)
+		buf, cur = cur.Show(buf)
+		buf, cur = cur.LeaveAlternateScreen(buf)
+		_, s.err = s.w.Write(buf)
+		s.term.Restore()
+	})
+	return s.err
+}
+
+// Recover restores the terminal if the calling goroutine is panicking, then
+// continues panicking.
+// A panic in a goroutine other than the one that deferred Close terminates the
+// program without running Close, so defer Recover at the top of every
+// goroutine that shares the terminal.
+//
+//	go func() {
+//		defer session.Recover()
+//		// ...
+//	}()
+func (s *Session) Recover() {
+	if r := recover(); r != nil {
+		s.Close()
+		panic(r)
+	}
+}
@@ -0,0 +1,73 @@
+package terminal
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pipeTerminal returns a terminal for a pipe, which is not a terminal, so
+// entering and leaving raw mode leave the test's own terminal alone.
+func pipeTerminal(t *testing.T) Terminal {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		r.Close()
+		w.Close()
+	})
+	return New(r.Fd())
+}
+
+func TestSession(t *testing.T) {
+	var out bytes.Buffer
+	session, err := NewSession(pipeTerminal(t), &out)
+	assert.NoError(t, err)
+	// Alternate screen, hidden cursor, bracketed paste, focus reporting.
+	assert.Equal(t, "\033[?1049h\033[?25l\033[?2004h\033[?1004h", out.String())
+
+	out.Reset()
+	assert.NoError(t, session.Close())
+	// Then in reverse, after disabling mouse reporting and resetting the
+	// graphic rendition and cursor shape.
+	assert.Equal(t, "\033[?1006l\033[?1003l\033[?1002l\033[?1000l"+
+		"\033[?1004l\033[?2004l\033[m\033[0 q\033[?25h\033[?1049l", out.String())
+
+	out.Reset()
+	assert.NoError(t, session.Close())
+	assert.Equal(t, "", out.String(), "closes once")
+}
+
+func TestSessionRestoresCursorShape(t *testing.T) {
+	var out bytes.Buffer
+	session, err := NewSession(pipeTerminal(t), &out)
+	assert.NoError(t, err)
+	out.Reset()
+	assert.NoError(t, session.Close())
+	assert.Contains(t, out.String(), "\033[0 q", "restores the default cursor shape")
+}
+
+func TestSessionRecover(t *testing.T) {
+	var out bytes.Buffer
+	session, err := NewSession(pipeTerminal(t), &out)
+	assert.NoError(t, err)
+	out.Reset()
+
+	assert.PanicsWithValue(t, "oops", func() {
+		defer session.Recover()
+		panic("oops")
+	})
+	assert.Contains(t, out.String(), "\033[?1049l", "restores the terminal")
+
+	// Without a panic, Recover leaves the session alone.
+	session, err = NewSession(pipeTerminal(t), &out)
+	assert.NoError(t, err)
+	out.Reset()
+	func() {
+		defer session.Recover()
+	}()
+	assert.Equal(t, "", out.String())
+}
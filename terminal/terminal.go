@@ -5,6 +5,9 @@ package terminal
 import (
 	"fmt"
 	"image"
+	"os"
+	"os/signal"
+	"sync"
 	"syscall"
 	"unsafe"
 
@@ -68,6 +71,52 @@ func (t Terminal) SetSize(size image.Point) error {
 	return SetSize(t.fd, size)
 }
 
+// Resizes returns a channel that receives the new bounds of the terminal
+// whenever its window changes size, as signaled by SIGWINCH, and a function
+// that stops the notifications.
+// The channel holds only the latest bounds, so a slow receiver skips
+// intermediate sizes while the user drags the window.
+// Stopping closes the channel, and is safe to do more than once.
+//
+//	resizes, stop := term.Resizes()
+//	defer stop()
+//	for bounds := range resizes {
+//		buf, cur, front, back = display.Resize(buf, cur, bounds)
+//	}
+func (t Terminal) Resizes() (<-chan image.Rectangle, func()) {
+	sigs := make(chan os.Signal, 1)
+	resizes := make(chan image.Rectangle, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, syscall.SIGWINCH)
+	go func() {
+		defer close(resizes)
+		for {
+			select {
+			case <-sigs:
+			case <-done:
+				return
+			}
+			bounds, err := t.Bounds()
+			if err != nil {
+				continue
+			}
+			// Replace stale bounds that have not been received.
+			select {
+			case <-resizes:
+			default:
+			}
+			resizes <- bounds
+		}
+	}()
+	var once sync.Once
+	return resizes, func() {
+		once.Do(func() {
+			signal.Stop(sigs)
+			close(done)
+		})
+	}
+}
+
 func bounds(fd uintptr) (image.Rectangle, error) {
 	size, err := size(fd)
 	if err != nil {
@@ -0,0 +1,21 @@
+package terminal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResizesStop(t *testing.T) {
+	var term Terminal
+	resizes, stop := term.Resizes()
+	stop()
+	assert.NotPanics(t, stop)
+	select {
+	case _, ok := <-resizes:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("resizes not closed")
+	}
+}
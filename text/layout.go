@@ -0,0 +1,354 @@
+package text
+
+import (
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/textile"
+)
+
+// Align is the horizontal alignment of each line of a layout.
+type Align int
+
+const (
+	// AlignLeft aligns lines on the left edge of the bounds.
+	AlignLeft Align = iota
+	// AlignCenter centers lines between the edges of the bounds.
+	AlignCenter
+	// AlignRight aligns lines on the right edge of the bounds.
+	AlignRight
+	// AlignJustify widens the gaps between words to align lines on both
+	// edges of the bounds, except the last line of each paragraph, which
+	// aligns left.
+	AlignJustify
+)
+
+// Layout flows text into a rectangle, wrapping paragraphs at word boundaries,
+// aligning lines, and truncating text that does not fit.
+// Like Write, a layout treats each newline as the end of a paragraph, expands
+// tabs to the next tab stop, and leaves spaces transparent.
+//
+// The zero value is a layout that aligns left and truncates rather than
+// wraps long lines, just as Write does.
+//
+//	layout := text.Layout{Wrap: true, Align: text.AlignCenter, Ellipsis: "…"}
+//	msgbox := layout.Measure(image.Rect(0, 0, 40, 10), msg)
+//	inset := rectangle.MiddleCenter(msgbox, bounds)
+//	panel := display.New(rectangle.Outset(inset, 2, 1))
+//	layout.Write(panel, inset, msg, display.Colors[7])
+type Layout struct {
+	// Align is the alignment of each line.
+	Align Align
+	// Wrap breaks lines at spaces so they fit the width of the bounds,
+	// breaking within a word only if the word alone is wider than the bounds.
+	// Without Wrap, each paragraph is a single line, truncated at the right
+	// edge.
+	Wrap bool
+	// Indent is a hanging indent, the number of cells to indent each line of
+	// a paragraph after the first.
+	Indent int
+	// Ellipsis, like "…" or "...", marks text truncated at the right edge of
+	// the bounds or after the last line that fits.
+	// Without an ellipsis, truncated text is simply clipped.
+	Ellipsis string
+}
+
+// glyph is a grapheme cluster placed in a line, the number of cells it
+// occupies, and the index of the span it came from.
+// Spaces and tabs are transparent gaps where lines may wrap.
+type glyph struct {
+	text  string
+	width int
+	space bool
+	span  int
+}
+
+// line is a sequence of glyphs, indented by some number of cells.
+// The last line of a paragraph is not justified.
+type line struct {
+	glyphs []glyph
+	indent int
+	last   bool
+}
+
+func (l line) width() int {
+	width := 0
+	for _, g := range l.glyphs {
+		width += g.width
+	}
+	return width
+}
+
+// trim removes trailing spaces from a line.
+func (l line) trim() line {
+	for len(l.glyphs) > 0 && l.glyphs[len(l.glyphs)-1].space {
+		l.glyphs = l.glyphs[:len(l.glyphs)-1]
+	}
+	return l
+}
+
+// Measure returns the rectangle that the text would occupy if written into
+// the given bounds, for sizing a panel to fit the text.
+// The rectangle covers every line that fits in the bounds, and spans the
+// columns from the leftmost to the rightmost glyph of any line.
+func (l Layout) Measure(bounds image.Rectangle, str string) image.Rectangle {
+	return l.MeasureSpans(bounds, []Span{{Text: str}})
+}
+
+// Write draws text onto a display within the given bounds, with the given
+// foreground color, returning the rectangle the text occupies.
+func (l Layout) Write(dst *display.Display, bounds image.Rectangle, str string, f color.Color) image.Rectangle {
+	return l.WriteSpans(dst, bounds, []Span{{Text: str, Foreground: f}})
+}
+
+// MeasureSpans returns the rectangle that a sequence of styled spans would
+// occupy if written into the given bounds.
+func (l Layout) MeasureSpans(bounds image.Rectangle, spans []Span) image.Rectangle {
+	return l.place(bounds, l.lines(bounds, paragraphs(spans)), nil)
+}
+
+// WriteSpans draws a sequence of styled spans onto a display within the given
+// bounds, returning the rectangle the text occupies.
+// The text of all the spans flows together, so a span may end in the middle
+// of a word or a line.
+// Spaces remain transparent in the text layer, but take the background color
+// of their span.
+func (l Layout) WriteSpans(dst *display.Display, bounds image.Rectangle, spans []Span) image.Rectangle {
+	return l.place(bounds, l.lines(bounds, paragraphs(spans)), func(pt image.Point, g glyph) {
+		s := spans[g.span]
+		if s.Background != nil {
+			for x := pt.X; x < pt.X+g.width; x++ {
+				dst.Background.Set(x, pt.Y, s.Background)
+			}
+		}
+		if g.space {
+			return
+		}
+		f := s.Foreground
+		if f == nil {
+			f = display.Colors[7]
+		}
+		dst.Text.Set(pt.X, pt.Y, g.text)
+		dst.Foreground.Set(pt.X, pt.Y, f)
+		dst.Attributes.Set(pt.X, pt.Y, s.Attr)
+		if g.width == 2 {
+			dst.Text.Set(pt.X+1, pt.Y, textile.Continuation)
+			dst.Foreground.Set(pt.X+1, pt.Y, f)
+			dst.Attributes.Set(pt.X+1, pt.Y, s.Attr)
+		}
+	})
+}
+
+// paragraphs splits the text of spans at newlines into paragraphs of glyphs,
+// ignoring a trailing newline and carriage returns.
+func paragraphs(spans []Span) [][]glyph {
+	last := len(spans) - 1
+	for last >= 0 && spans[last].Text == "" {
+		last--
+	}
+	if last < 0 {
+		return nil
+	}
+	var paras [][]glyph
+	var para []glyph
+	for i, span := range spans[:last+1] {
+		str := span.Text
+		if i == last {
+			str = strings.TrimSuffix(str, "\n")
+		}
+		for str != "" {
+			c, w := textile.Cluster(str)
+			str = str[len(c):]
+			switch {
+			case c == "\n":
+				paras = append(paras, para)
+				para = nil
+			case c == " " || c == "\t":
+				para = append(para, glyph{text: c, width: 1, space: true, span: i})
+			case w > 0:
+				para = append(para, glyph{text: c, width: w, span: i})
+			}
+		}
+	}
+	if len(paras) == 0 && len(para) == 0 {
+		return nil
+	}
+	return append(paras, para)
+}
+
+// lines breaks paragraphs into lines that fit the width of the bounds,
+// expanding tabs, then truncates the lines to fit the bounds.
+func (l Layout) lines(bounds image.Rectangle, paras [][]glyph) []line {
+	width := bounds.Dx()
+	var lines []line
+	for _, para := range paras {
+		cur := line{}
+		x := 0
+		wrapped := false
+		// breakLine ends the current line and begins the next line of the
+		// paragraph with the hanging indent.
+		breakLine := func() {
+			lines = append(lines, cur.trim())
+			cur = line{indent: l.Indent}
+			x = l.Indent
+			wrapped = true
+		}
+		for i := 0; i < len(para); {
+			g := para[i]
+			if g.space {
+				if wrapped && len(cur.glyphs) == 0 {
+					// Swallow spaces at the beginning of wrapped lines.
+					i++
+					continue
+				}
+				if g.text == "\t" {
+					g.width = (x+tabStopWidth)/tabStopWidth*tabStopWidth - x
+				}
+				cur.glyphs = append(cur.glyphs, g)
+				x += g.width
+				i++
+				continue
+			}
+			word := i
+			for word < len(para) && !para[word].space {
+				word++
+			}
+			ww := 0
+			for _, g := range para[i:word] {
+				ww += g.width
+			}
+			if l.Wrap && x+ww > width && len(cur.trim().glyphs) > 0 {
+				breakLine()
+				continue
+			}
+			for _, g := range para[i:word] {
+				if l.Wrap && x+g.width > width && len(cur.glyphs) > 0 {
+					// Break within a word that is wider than the bounds.
+					breakLine()
+				}
+				cur.glyphs = append(cur.glyphs, g)
+				x += g.width
+			}
+			i = word
+		}
+		cur = cur.trim()
+		cur.last = true
+		lines = append(lines, cur)
+	}
+
+	for i := range lines {
+		avail := width - lines[i].indent
+		if lines[i].width() > avail {
+			lines[i] = l.truncate(lines[i], avail)
+		}
+	}
+	if len(lines) > bounds.Dy() {
+		lines = lines[:bounds.Dy()]
+		if len(lines) > 0 {
+			last := &lines[len(lines)-1]
+			*last = l.truncate(*last, width-last.indent)
+			last.last = true
+		}
+	}
+	return lines
+}
+
+// truncate shortens a line to fit the available width, including the
+// ellipsis, which it always appends in the style of the first glyph it
+// removes.
+func (l Layout) truncate(ln line, avail int) line {
+	ellipsis := paragraphs([]Span{{Text: l.Ellipsis}})
+	ew := 0
+	if len(ellipsis) > 0 {
+		ew = line{glyphs: ellipsis[0]}.width()
+	}
+	glyphs := make([]glyph, 0, len(ln.glyphs))
+	span := 0
+	if len(ln.glyphs) > 0 {
+		span = ln.glyphs[len(ln.glyphs)-1].span
+	}
+	x := 0
+	for _, g := range ln.glyphs {
+		if x+g.width > avail-ew {
+			span = g.span
+			break
+		}
+		glyphs = append(glyphs, g)
+		x += g.width
+	}
+	ln.glyphs = glyphs
+	ln = ln.trim()
+	if len(ellipsis) > 0 && ew <= avail {
+		for _, g := range ellipsis[0] {
+			g.span = span
+			ln.glyphs = append(ln.glyphs, g)
+		}
+	}
+	return ln
+}
+
+// place positions each line within the bounds according to the alignment,
+// calling draw for every glyph, and returns the rectangle that the lines
+// occupy.
+func (l Layout) place(bounds image.Rectangle, lines []line, draw func(image.Point, glyph)) image.Rectangle {
+	used := image.Rectangle{bounds.Min, bounds.Min}
+	used.Max.Y += len(lines)
+	empty := true
+	for i, ln := range lines {
+		avail := bounds.Dx() - ln.indent
+		lw := ln.width()
+		x := bounds.Min.X + ln.indent
+		var gaps, extra int
+		switch l.Align {
+		case AlignCenter:
+			x += (avail - lw) / 2
+		case AlignRight:
+			x += avail - lw
+		case AlignJustify:
+			if gaps = countGaps(ln.glyphs); gaps > 0 && !ln.last {
+				extra = avail - lw
+			} else {
+				gaps = 0
+			}
+		}
+		if lw > 0 {
+			if empty || x < used.Min.X {
+				used.Min.X = x
+			}
+			if empty || x+lw+extra > used.Max.X {
+				used.Max.X = x + lw + extra
+			}
+			empty = false
+		}
+		y := bounds.Min.Y + i
+		for j, g := range ln.glyphs {
+			if g.space {
+				if gaps > 0 && j > 0 && !ln.glyphs[j-1].space {
+					// Widen the first space of each gap between words.
+					widen := extra / gaps
+					extra -= widen
+					gaps--
+					x += widen
+				}
+			}
+			if draw != nil {
+				draw(image.Pt(x, y), g)
+			}
+			x += g.width
+		}
+	}
+	return used
+}
+
+// countGaps counts the runs of spaces between words.
+func countGaps(glyphs []glyph) int {
+	gaps := 0
+	for j, g := range glyphs {
+		if g.space && j > 0 && !glyphs[j-1].space {
+			gaps++
+		}
+	}
+	return gaps
+}
@@ -0,0 +1,92 @@
+package text
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/textile"
+	"github.com/stretchr/testify/assert"
+)
+
+// rows returns the text layer of a display as one string per row, with
+// blanks for transparent cells.
+func rows(d *display.Display) []string {
+	var rows []string
+	for y := d.Rect.Min.Y; y < d.Rect.Max.Y; y++ {
+		var row strings.Builder
+		for x := d.Rect.Min.X; x < d.Rect.Max.X; x++ {
+			switch t := d.Text.At(x, y); t {
+			case "":
+				row.WriteString(" ")
+			case textile.Continuation:
+			default:
+				row.WriteString(t)
+			}
+		}
+		rows = append(rows, row.String())
+	}
+	return rows
+}
+
+// layout writes text with a layout and checks that the layout measures the
+// same rectangle that it writes.
+func layout(t *testing.T, l Layout, bounds image.Rectangle, str string) ([]string, image.Rectangle) {
+	d := display.New(bounds)
+	used := l.Write(d, bounds, str, display.Colors[7])
+	assert.Equal(t, used, l.Measure(bounds, str))
+	return rows(d), used
+}
+
+func TestLayoutWrap(t *testing.T) {
+	lines, used := layout(t, Layout{Wrap: true}, image.Rect(0, 0, 10, 4), "The quick brown fox jumps over")
+	assert.Equal(t, []string{
+		"The quick ",
+		"brown fox ",
+		"jumps over",
+		"          ",
+	}, lines)
+	assert.Equal(t, image.Rect(0, 0, 10, 3), used)
+}
+
+func TestLayoutWrapLongWord(t *testing.T) {
+	lines, _ := layout(t, Layout{Wrap: true}, image.Rect(0, 0, 4, 3), "a abcdefg")
+	assert.Equal(t, []string{
+		"a   ",
+		"abcd",
+		"efg ",
+	}, lines)
+}
+
+func TestLayoutAlign(t *testing.T) {
+	bounds := image.Rect(0, 0, 9, 2)
+	lines, used := layout(t, Layout{Wrap: true, Align: AlignCenter}, bounds, "one two three")
+	assert.Equal(t, []string{" one two ", "  three  "}, lines)
+	assert.Equal(t, image.Rect(1, 0, 8, 2), used)
+	lines, _ = layout(t, Layout{Wrap: true, Align: AlignRight}, bounds, "one two three")
+	assert.Equal(t, []string{"  one two", "    three"}, lines)
+	lines, _ = layout(t, Layout{Wrap: true, Align: AlignJustify}, bounds, "one two three")
+	assert.Equal(t, []string{"one   two", "three    "}, lines)
+}
+
+func TestLayoutIndent(t *testing.T) {
+	lines, _ := layout(t, Layout{Wrap: true, Indent: 2}, image.Rect(0, 0, 8, 3), "- one two three")
+	assert.Equal(t, []string{
+		"- one   ",
+		"  two   ",
+		"  three ",
+	}, lines)
+}
+
+func TestLayoutTruncate(t *testing.T) {
+	lines, _ := layout(t, Layout{Ellipsis: "…"}, image.Rect(0, 0, 6, 2), "abcdefgh\nabc")
+	assert.Equal(t, []string{"abcde…", "abc   "}, lines)
+	lines, _ = layout(t, Layout{Wrap: true, Ellipsis: "..."}, image.Rect(0, 0, 8, 2), "one two three four five")
+	assert.Equal(t, []string{"one two ", "three..."}, lines)
+}
+
+func TestLayoutWide(t *testing.T) {
+	lines, _ := layout(t, Layout{Wrap: true}, image.Rect(0, 0, 5, 2), "日本語の")
+	assert.Equal(t, []string{"日本 ", "語の "}, lines)
+}
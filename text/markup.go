@@ -0,0 +1,191 @@
+package text
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/kriskowal/cops/display"
+)
+
+// Span is a run of text with its own foreground and background colors and
+// text attributes.
+// A nil foreground writes the default white (7) and a nil background leaves
+// the background of the display as it was.
+type Span struct {
+	Text       string
+	Foreground color.Color
+	Background color.Color
+	Attr       display.Attr
+}
+
+// Markup parses text with inline style tags into styled spans, suitable for
+// the WriteSpans method of a Layout.
+//
+//	spans, err := text.Markup("[bold red]error[/] in [underline]main.go[/]")
+//
+// A tag is a bracketed list of words, each of which is an attribute, a
+// foreground color, or "on" followed by a background color.
+// The attributes are bold, dim, italic, underline, blink, reverse, and
+// strikethrough.
+// A color is a name, like red or bright-red, a 256 color palette index, like
+// color(208), or a hexadecimal RGB triple, like #ff8800.
+// The names are black, red, green, yellow, blue, magenta, cyan, and white,
+// their bright- variants, and gray.
+//
+// Each tag adds to the style of the tags before it, until the tag "[/]"
+// restores the style from before the most recent unclosed tag.
+// Brackets that do not enclose a tag, like "[0]" or "[b]", are literal text.
+// Use "[[" for a literal bracket before text that would be a tag, and Escape
+// for text from elsewhere, like a file name.
+func Markup(str string) ([]Span, error) {
+	var spans []Span
+	stack := []Span{{}}
+	var text strings.Builder
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		span := stack[len(stack)-1]
+		span.Text = text.String()
+		text.Reset()
+		if n := len(spans); n > 0 && sameStyle(spans[n-1], span) {
+			spans[n-1].Text += span.Text
+		} else {
+			spans = append(spans, span)
+		}
+	}
+	for str != "" {
+		i := strings.IndexByte(str, '[')
+		if i < 0 {
+			text.WriteString(str)
+			break
+		}
+		text.WriteString(str[:i])
+		str = str[i+1:]
+		if strings.HasPrefix(str, "[") {
+			text.WriteByte('[')
+			str = str[1:]
+			continue
+		}
+		j := strings.IndexByte(str, ']')
+		if j < 0 {
+			text.WriteByte('[')
+			continue
+		}
+		tag := str[:j]
+		if tag == "/" {
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("unbalanced markup tag %q", "[/]")
+			}
+			flush()
+			stack = stack[:len(stack)-1]
+			str = str[j+1:]
+			continue
+		}
+		span, ok := parseTag(stack[len(stack)-1], tag)
+		if !ok {
+			// Not a tag, so the bracket is literal.
+			text.WriteByte('[')
+			continue
+		}
+		flush()
+		stack = append(stack, span)
+		str = str[j+1:]
+	}
+	flush()
+	return spans, nil
+}
+
+// sameStyle returns whether two spans have the same colors and attributes.
+func sameStyle(a, b Span) bool {
+	return a.Foreground == b.Foreground && a.Background == b.Background && a.Attr == b.Attr
+}
+
+// parseTag applies the words of a tag to a style, or returns false if the
+// tag has a word that is neither an attribute nor a color.
+func parseTag(span Span, tag string) (Span, bool) {
+	words := strings.Fields(tag)
+	if len(words) == 0 {
+		return span, false
+	}
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if a, ok := attrNames[word]; ok {
+			span.Attr |= a
+		} else if word == "on" && i+1 < len(words) {
+			i++
+			c, ok := parseColor(words[i])
+			if !ok {
+				return span, false
+			}
+			span.Background = c
+		} else if c, ok := parseColor(word); ok {
+			span.Foreground = c
+		} else {
+			return span, false
+		}
+	}
+	return span, true
+}
+
+// Escape returns text with its brackets escaped, so that markup shows it
+// literally.
+//
+//	spans, err := text.Markup("[bold]" + text.Escape(name) + "[/]")
+func Escape(str string) string {
+	return strings.Replace(str, "[", "[[", -1)
+}
+
+// parseColor parses a color name, palette index, or hexadecimal RGB triple.
+func parseColor(word string) (color.Color, bool) {
+	if i, ok := colorNames[word]; ok {
+		return display.Colors[i], true
+	}
+	if strings.HasPrefix(word, "#") && len(word) == 7 {
+		rgb, err := strconv.ParseUint(word[1:], 16, 32)
+		if err != nil {
+			return nil, false
+		}
+		return color.RGBA{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb), 255}, true
+	}
+	if strings.HasPrefix(word, "color(") && strings.HasSuffix(word, ")") {
+		i, err := strconv.Atoi(word[len("color(") : len(word)-1])
+		if err == nil && i >= 0 && i < len(display.Colors) {
+			return display.Colors[i], true
+		}
+	}
+	return nil, false
+}
+
+var attrNames = map[string]display.Attr{
+	"bold":          display.Bold,
+	"italic":        display.Italic,
+	"underline":     display.Underline,
+	"blink":         display.Blink,
+	"reverse":       display.Reverse,
+	"strikethrough": display.Strikethrough,
+	"dim":           display.Dim,
+}
+
+// colorNames maps color names to indexes of the terminal palette.
+var colorNames = map[string]int{
+	"black":          0,
+	"red":            1,
+	"green":          2,
+	"yellow":         3,
+	"blue":           4,
+	"magenta":        5,
+	"cyan":           6,
+	"white":          7,
+	"gray":           8,
+	"bright-black":   8,
+	"bright-red":     9,
+	"bright-green":   10,
+	"bright-yellow":  11,
+	"bright-blue":    12,
+	"bright-magenta": 13,
+	"bright-cyan":    14,
+	"bright-white":   15,
+}
@@ -0,0 +1,90 @@
+package text
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/displaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkup(t *testing.T) {
+	spans, err := Markup("[bold red]error[/] in [underline]main[[1].go[/]")
+	assert.NoError(t, err)
+	assert.Equal(t, []Span{
+		{Text: "error", Foreground: display.Colors[1], Attr: display.Bold},
+		{Text: " in "},
+		{Text: "main[1].go", Attr: display.Underline},
+	}, spans)
+}
+
+func TestMarkupNesting(t *testing.T) {
+	spans, err := Markup("[on #203040]a[color(208)]b[/]c[/]d")
+	assert.NoError(t, err)
+	bg := color.RGBA{0x20, 0x30, 0x40, 255}
+	assert.Equal(t, []Span{
+		{Text: "a", Background: bg},
+		{Text: "b", Foreground: display.Colors[208], Background: bg},
+		{Text: "c", Background: bg},
+		{Text: "d"},
+	}, spans)
+}
+
+func TestMarkupErrors(t *testing.T) {
+	_, err := Markup("x[/]")
+	assert.EqualError(t, err, `unbalanced markup tag "[/]"`)
+}
+
+func TestMarkupLiteralBrackets(t *testing.T) {
+	for _, str := range []string{
+		"a[0]", "[b]", "[mauve]x", "[bold", "[]", "[on]", "[on mauve]", "x[color(256)]", "f(a[i], [1, 2])",
+	} {
+		spans, err := Markup(str)
+		assert.NoError(t, err, str)
+		assert.Equal(t, []Span{{Text: str}}, spans, str)
+	}
+
+	// A bracket that is not a tag does not hide one that follows.
+	spans, err := Markup("[x [red]y")
+	assert.NoError(t, err)
+	assert.Equal(t, []Span{
+		{Text: "[x "},
+		{Text: "y", Foreground: display.Colors[1]},
+	}, spans)
+}
+
+func TestEscape(t *testing.T) {
+	name := "[red] [[x]].go"
+	spans, err := Markup("[bold]" + Escape(name) + "[/]")
+	assert.NoError(t, err)
+	assert.Equal(t, []Span{{Text: name, Attr: display.Bold}}, spans)
+}
+
+func TestWriteSpans(t *testing.T) {
+	spans, err := Markup("[red]error[/] in [bold]file[/]")
+	assert.NoError(t, err)
+	bounds := image.Rect(0, 0, 8, 2)
+	d := display.New(bounds)
+	used := Layout{Wrap: true}.WriteSpans(d, bounds, spans)
+	assert.Equal(t, image.Rect(0, 0, 8, 2), used)
+	assert.Equal(t, []string{"error in", "file    "}, rows(d))
+	_, f, _ := d.At(0, 0)
+	a := d.AttrAt(0, 0)
+	assert.Equal(t, display.Colors[1], f)
+	assert.Equal(t, display.Attr(0), a)
+	_, f, _ = d.At(0, 1)
+	a = d.AttrAt(0, 1)
+	assert.Equal(t, display.Colors[7], f)
+	assert.Equal(t, display.Bold, a)
+}
+
+func TestWriteSpansGolden(t *testing.T) {
+	spans, err := Markup("[bold yellow on blue]Warning:[/] the [underline]cops[/] are [italic #ff8800]here[/].")
+	assert.NoError(t, err)
+	bounds := image.Rect(0, 0, 14, 3)
+	d := display.New(bounds)
+	Layout{Wrap: true, Align: AlignCenter}.WriteSpans(d, bounds, spans)
+	displaytest.Golden(t, "spans", d)
+}
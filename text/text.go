@@ -1,7 +1,10 @@
 // Package text measures and cuts raw text for terminal displays.
 // Rather that implement the gaummut of virtual terminal commands,
 // the text package recognizes only newline "\n", tab "\t", and space " ",
-// assuming all other characters are printable in a single cell.
+// assuming all other characters are printable.
+// The text package groups characters into grapheme clusters, so combining
+// marks and joined emoji occupy the same cell as the character before them,
+// and East Asian wide characters occupy two cells.
 // The text package treats white space as transparent, only writing the text
 // and foreground color layer for each cell that contains opaque text.
 package text
@@ -11,6 +14,7 @@ import (
 	"image/color"
 
 	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/textile"
 )
 
 const tabStopWidth = 8
@@ -19,14 +23,16 @@ const tabStopWidth = 8
 func Bounds(str string) image.Rectangle {
 	width, height := 0, 0
 	x, y := 0, 1
-	for _, r := range str {
-		if r == '\n' {
+	for str != "" {
+		c, w := textile.Cluster(str)
+		str = str[len(c):]
+		if c == "\n" {
 			y++
 			x = 0
-		} else if r == '\t' {
+		} else if c == "\t" {
 			x = ((x + tabStopWidth) / tabStopWidth) * tabStopWidth
-		} else {
-			x++
+		} else if w > 0 {
+			x += w
 			if x > width {
 				width = x
 			}
@@ -39,24 +45,33 @@ func Bounds(str string) image.Rectangle {
 }
 
 // Write draws a message onto a display in the given bounds and with the given color.
+// Write omits wide characters that would straddle the right edge of the
+// bounds.
 func Write(dst *display.Display, bounds image.Rectangle, str string, f color.Color) {
 	x, y := 0, 0
-	for _, r := range str {
-		if r == '\n' {
+	for str != "" {
+		c, w := textile.Cluster(str)
+		str = str[len(c):]
+		if c == "\n" {
 			y++
 			x = 0
-		} else if r == '\r' {
-		} else if r == '\t' {
+		} else if c == "\r" {
+		} else if c == "\t" {
 			x = ((x + tabStopWidth) / tabStopWidth) * tabStopWidth
-		} else if r == ' ' {
+		} else if c == " " {
 			x++
-		} else {
+		} else if w > 0 {
 			pt := image.Pt(x, y).Add(bounds.Min)
-			if pt.In(bounds) {
-				dst.Text.Set(pt.X, pt.Y, string(r))
+			end := image.Pt(x+w-1, y).Add(bounds.Min)
+			if pt.In(bounds) && end.In(bounds) {
+				dst.Text.Set(pt.X, pt.Y, c)
 				dst.Foreground.Set(pt.X, pt.Y, f)
+				if w == 2 {
+					dst.Text.Set(end.X, end.Y, textile.Continuation)
+					dst.Foreground.Set(end.X, end.Y, f)
+				}
 			}
-			x++
+			x += w
 		}
 	}
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/kriskowal/cops/display"
 	"github.com/kriskowal/cops/rectangle"
+	"github.com/kriskowal/cops/textile"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,7 +23,7 @@ func TestRender(t *testing.T) {
 	bounds := Bounds(str)
 	front := display.New(bounds)
 	back := display.New(bounds)
-	front.Fill("", display.Colors[7], display.Colors[0])
+	front.Fill(bounds, "", display.Colors[7], display.Colors[0])
 	Write(front, bounds, str, display.Colors[7])
 	var buf []byte
 	cur := display.Reset
@@ -36,10 +37,47 @@ func TestOffset(t *testing.T) {
 	outset := rectangle.Outset(bounds, 2, 1)
 	front := display.New(outset)
 	back := display.New(outset)
-	front.Fill(".", display.Colors[7], display.Colors[0])
+	front.Fill(outset, ".", display.Colors[7], display.Colors[0])
 	Write(front, bounds, str, display.Colors[7])
 	var buf []byte
 	cur := display.Reset
 	buf, cur = display.RenderOver(buf, cur, front, back, display.Model0)
 	assert.Equal(t, ".......\r\n..abc..\r\n.......", string(buf))
 }
+
+func TestOffsetCompressed(t *testing.T) {
+	str := "abc"
+	bounds := Bounds(str).Add(image.Pt(2, 1))
+	outset := rectangle.Outset(bounds, 2, 1)
+	front := display.New(outset)
+	back := display.New(outset)
+	front.Fill(outset, ".", display.Colors[7], display.Colors[0])
+	Write(front, bounds, str, display.Colors[7])
+	var buf []byte
+	cur := display.Reset
+	buf, cur = display.RenderCompressed(buf, cur, front, back, display.Model0, display.CompressRepeat)
+	// Runs of the same character render with REP.
+	assert.Equal(t, ".\033[6b\r\n..abc..\r\n.\033[6b", string(buf))
+}
+
+func TestBoundsWide(t *testing.T) {
+	assert.Equal(t, image.Rect(0, 0, 4, 1), Bounds("日本"))
+	assert.Equal(t, image.Rect(0, 0, 2, 1), Bounds("e\u0301x"))
+	assert.Equal(t, image.Rect(0, 0, 3, 1), Bounds("👍🏻a"))
+}
+
+func TestWriteWide(t *testing.T) {
+	str := "日本.txt"
+	bounds := Bounds(str)
+	front := display.New(bounds)
+	back := display.New(bounds)
+	front.Fill(bounds, "", display.Colors[7], display.Colors[0])
+	Write(front, bounds, str, display.Colors[7])
+	assert.Equal(t, "日", front.Text.At(0, 0))
+	assert.Equal(t, textile.Continuation, front.Text.At(1, 0))
+	assert.Equal(t, ".", front.Text.At(4, 0))
+	var buf []byte
+	cur := display.Reset
+	buf, cur = display.RenderOver(buf, cur, front, back, display.Model0)
+	assert.Equal(t, "日本.txt", string(buf))
+}
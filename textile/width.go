@@ -0,0 +1,191 @@
+package textile
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Continuation marks a cell covered by the right half of a wide glyph in the
+// cell to its left.
+// A wide glyph renders only if the next cell is a continuation, and a
+// continuation renders only with the wide glyph before it, so a wide glyph or
+// continuation that loses its partner, for example when drawing over half of
+// it, renders as a blank cell instead.
+const Continuation = "\x00"
+
+// Cluster returns the first grapheme cluster of a string, which is the
+// sequence of runes that a terminal renders as a single glyph, and the number
+// of cells the glyph occupies: 0, 1, or 2.
+//
+// A cluster is a base character followed by any combining marks, variation
+// selectors, or emoji skin tone modifiers, possibly joined to further
+// characters with zero width joiners.
+// A pair of regional indicators forms a single flag.
+//
+// The width is 2 for East Asian wide and full width characters and for emoji
+// presented as pictures, 0 for a cluster of only zero width characters, and 1
+// otherwise.
+func Cluster(s string) (string, int) {
+	if s == "" {
+		return "", 0
+	}
+	base, n := utf8.DecodeRuneInString(s)
+	width := runeWidth(base)
+	joined := false
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		switch {
+		case joined:
+			joined = false
+		case r == zeroWidthJoiner:
+			joined = true
+		case r == presentationSelector:
+			width = 2
+		case isRegionalIndicator(base) && isRegionalIndicator(r) && n == utf8.RuneLen(base):
+			// The second of a pair of regional indicators.
+		case isExtender(r):
+		default:
+			return s[:n], width
+		}
+		n += size
+	}
+	return s, width
+}
+
+// Width returns the number of cells a string occupies, as the sum of the
+// widths of its grapheme clusters.
+func Width(s string) int {
+	width := 0
+	for s != "" {
+		c, w := Cluster(s)
+		width += w
+		s = s[len(c):]
+	}
+	return width
+}
+
+const (
+	zeroWidthJoiner      = 0x200d
+	presentationSelector = 0xfe0f
+)
+
+// isExtender returns whether a rune extends the grapheme cluster before it
+// without occupying a cell of its own.
+func isExtender(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		(r >= 0xfe00 && r <= 0xfe0f) || // variation selectors
+		(r >= 0x1f3fb && r <= 0x1f3ff) || // emoji skin tone modifiers
+		(r >= 0xe0020 && r <= 0xe007f) || // tags
+		(r >= 0xe0100 && r <= 0xe01ef) // variation selectors supplement
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// runeWidth returns the number of cells a single rune occupies.
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || (r >= 0x7f && r < 0xa0):
+		return 0
+	case r < 0x1100:
+		if isExtender(r) {
+			return 0
+		}
+		return 1
+	case r >= 0x1160 && r <= 0x11ff:
+		// Hangul medial vowels and final consonants combine with the
+		// initial consonant before them.
+		return 0
+	case isExtender(r) || unicode.Is(unicode.Cf, r):
+		return 0
+	case isRegionalIndicator(r):
+		return 2
+	case inTable(r, wide):
+		return 2
+	}
+	return 1
+}
+
+func inTable(r rune, table [][2]rune) bool {
+	lo, hi := 0, len(table)
+	for lo < hi {
+		m := (lo + hi) / 2
+		switch {
+		case r < table[m][0]:
+			hi = m
+		case r > table[m][1]:
+			lo = m + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// wide contains the ranges of East Asian wide and full width characters and
+// emoji with default picture presentation, in ascending order.
+var wide = [][2]rune{
+	{0x1100, 0x115f},
+	{0x231a, 0x231b},
+	{0x2329, 0x232a},
+	{0x23e9, 0x23ec},
+	{0x23f0, 0x23f0},
+	{0x23f3, 0x23f3},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267f, 0x267f},
+	{0x2693, 0x2693},
+	{0x26a1, 0x26a1},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x26ce, 0x26ce},
+	{0x26d4, 0x26d4},
+	{0x26ea, 0x26ea},
+	{0x26f2, 0x26f3},
+	{0x26f5, 0x26f5},
+	{0x26fa, 0x26fa},
+	{0x26fd, 0x26fd},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2728, 0x2728},
+	{0x274c, 0x274c},
+	{0x274e, 0x274e},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27b0, 0x27b0},
+	{0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50},
+	{0x2b55, 0x2b55},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xa960, 0xa97f},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x16fe0, 0x16fe4},
+	{0x17000, 0x18aff},
+	{0x1b000, 0x1b2ff},
+	{0x1f004, 0x1f004},
+	{0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a},
+	{0x1f200, 0x1f251},
+	{0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb},
+	{0x1f90c, 0x1f9ff},
+	{0x1fa70, 0x1faff},
+	{0x20000, 0x2fffd},
+	{0x30000, 0x3fffd},
+}
@@ -0,0 +1,27 @@
+package textile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCluster(t *testing.T) {
+	for _, c := range []struct {
+		str, cluster string
+		width        int
+	}{
+		{"ab", "a", 1},
+		{"e\u0301x", "e\u0301", 1},
+		{"日本", "日", 2},
+		{"👍🏻!", "👍🏻", 2},
+		{"👩\u200d💻x", "👩\u200d💻", 2},
+		{"🇯🇵🇺🇸", "🇯🇵", 2},
+		{"☺\ufe0fx", "☺\ufe0f", 2},
+		{"\u200bx", "\u200b", 0},
+	} {
+		cluster, width := Cluster(c.str)
+		assert.Equal(t, c.cluster, cluster, "cluster of %q", c.str)
+		assert.Equal(t, c.width, width, "width of %q", c.str)
+	}
+}
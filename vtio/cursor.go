@@ -0,0 +1,40 @@
+package vtio
+
+import (
+	"image"
+
+	"github.com/kriskowal/cops/display"
+)
+
+// Cursor is the state of the cursor of the terminal a display writer
+// emulates, for a host to draw the cursor where the program expects it.
+type Cursor struct {
+	// Position is the cell of the cursor, in the coordinates of the display
+	// writer's rectangle.
+	Position image.Point
+	// Visible is whether the program shows the cursor.
+	Visible bool
+	// Shape is the shape of the cursor the program requests.
+	Shape display.CursorShape
+}
+
+// Cursor returns the state of the emulated cursor.
+//
+//	vc := vtw.Cursor()
+//	if vc.Visible {
+//		buf, cur = cur.Go(buf, vc.Position)
+//		buf, cur = cur.SetShape(buf, vc.Shape)
+//		buf, cur = cur.Show(buf)
+//	} else {
+//		buf, cur = cur.Hide(buf)
+//	}
+func (d *DisplayWriter) Cursor() Cursor {
+	d.handler.lock.RLock()
+	defer d.handler.lock.RUnlock()
+	h := d.handler
+	return Cursor{
+		Position: h.pos,
+		Visible:  !h.hidden,
+		Shape:    h.shape,
+	}
+}
@@ -0,0 +1,30 @@
+package vtio
+
+import (
+	"image"
+	"testing"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 4, 2))
+	assert.Equal(t, Cursor{Position: image.Pt(0, 0), Visible: true}, vtw.Cursor())
+
+	vtw.Write([]byte("\033[2;3H\033[?25l\033[5 q"))
+	assert.Equal(t, Cursor{
+		Position: image.Pt(2, 1),
+		Visible:  false,
+		Shape:    display.CursorBlinkingBar,
+	}, vtw.Cursor())
+
+	vtw.Write([]byte("\033[?25h\033[ q"))
+	assert.Equal(t, Cursor{Position: image.Pt(2, 1), Visible: true}, vtw.Cursor())
+}
+
+func TestCursorAtLastColumn(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 4, 2))
+	vtw.Write([]byte("abcd"))
+	assert.Equal(t, image.Pt(3, 0), vtw.Cursor().Position)
+}
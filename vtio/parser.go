@@ -0,0 +1,282 @@
+package vtio
+
+// parser interprets a stream of terminal output, dispatching printable text,
+// control characters, and escape sequences to a handler.
+// The parser follows the state machine of the DEC VT500 series, recognizing
+// escape sequences, control sequences (CSI), and strings (OSC, DCS, and
+// others), and passes bytes of UTF-8 encoded text through to the handler
+// as printable.
+type parser struct {
+	handler *displayWriterHandler
+	state   parserState
+	// prefix is the private parameter prefix of a control sequence, like
+	// "?", or zero.
+	prefix byte
+	params []byte
+	// inter holds the intermediate bytes of an escape or control sequence.
+	inter []byte
+	// esc indicates that the last byte of a string was an escape, which may
+	// begin the string terminator.
+	esc bool
+}
+
+type parserState int
+
+const (
+	stateGround parserState = iota
+	stateEscape
+	stateCSI
+	stateString
+)
+
+const (
+	bel = 0x07
+	can = 0x18
+	sub = 0x1a
+	esc = 0x1b
+	del = 0x7f
+)
+
+// Parse interprets a buffer of terminal output.
+// Escape sequences may span multiple calls.
+func (p *parser) Parse(buf []byte) (int, error) {
+	for _, b := range buf {
+		p.parse(b)
+	}
+	return len(buf), nil
+}
+
+func (p *parser) parse(b byte) {
+	h := p.handler
+	if p.state == stateString {
+		// Strings end at BEL or ST (ESC \), and their contents are ignored.
+		switch {
+		case b == bel:
+			p.state = stateGround
+		case p.esc && b == '\\':
+			p.state = stateGround
+		case p.esc:
+			// Any other escape sequence aborts the string.
+			p.esc = false
+			p.begin(stateEscape)
+			p.parse(b)
+			return
+		default:
+			p.esc = b == esc
+		}
+		return
+	}
+
+	switch {
+	case b == esc:
+		p.begin(stateEscape)
+		return
+	case b == can || b == sub:
+		p.state = stateGround
+		return
+	case b < 0x20:
+		// Control characters take effect even in the middle of a sequence.
+		h.Execute(b)
+		return
+	case b == del:
+		return
+	}
+
+	switch p.state {
+	case stateGround:
+		h.Print(b)
+
+	case stateEscape:
+		switch {
+		case b >= 0x20 && b < 0x30:
+			p.inter = append(p.inter, b)
+		case len(p.inter) > 0:
+			p.state = stateGround
+			p.escDispatch(b)
+		case b == '[':
+			p.begin(stateCSI)
+		case b == ']' || b == 'P' || b == 'X' || b == '^' || b == '_':
+			p.begin(stateString)
+		default:
+			p.state = stateGround
+			p.escDispatch(b)
+		}
+
+	case stateCSI:
+		switch {
+		case b >= 0x30 && b < 0x40:
+			if len(p.params) == 0 && p.prefix == 0 && b >= '<' {
+				p.prefix = b
+			} else {
+				p.params = append(p.params, b)
+			}
+		case b >= 0x20 && b < 0x30:
+			p.inter = append(p.inter, b)
+		case b >= 0x40 && b < 0x7f:
+			p.state = stateGround
+			p.csiDispatch(b)
+		default:
+			// Bytes of UTF-8 within a control sequence abort it.
+			p.state = stateGround
+		}
+	}
+}
+
+// begin enters a state, clearing the sequence collected so far.
+func (p *parser) begin(state parserState) {
+	p.state = state
+	p.prefix = 0
+	p.params = p.params[:0]
+	p.inter = p.inter[:0]
+	p.esc = false
+}
+
+func (p *parser) escDispatch(b byte) {
+	h := p.handler
+	if len(p.inter) > 0 {
+		// Character set designations, like ESC ( B, and others are
+		// ignored.
+		return
+	}
+	switch b {
+	case '7':
+		h.DECSC()
+	case '8':
+		h.DECRC()
+	case 'D':
+		h.IND()
+	case 'E': // Next line
+		h.Execute('\r')
+		h.Execute('\n')
+	case 'M':
+		h.RI()
+	}
+}
+
+// ints returns the parameters of a control sequence, separated by
+// semicolons, with 0 for missing parameters, disregarding subparameters.
+func (p *parser) ints() []int {
+	groups := parseParams(p.params)
+	if len(groups) == 0 {
+		return nil
+	}
+	ints := make([]int, len(groups))
+	for i, group := range groups {
+		ints[i] = group[0]
+	}
+	return ints
+}
+
+// parseParams parses the parameters of a control sequence, separated by
+// semicolons, each with any subparameters separated by colons, with 0 for
+// missing parameters.
+func parseParams(params []byte) [][]int {
+	if len(params) == 0 {
+		return nil
+	}
+	groups := [][]int{{0}}
+	for _, b := range params {
+		group := &groups[len(groups)-1]
+		switch {
+		case b == ';':
+			groups = append(groups, []int{0})
+		case b == ':':
+			*group = append(*group, 0)
+		case b >= '0' && b <= '9':
+			n := &(*group)[len(*group)-1]
+			if *n < 1<<16 {
+				*n = *n*10 + int(b-'0')
+			}
+		}
+	}
+	return groups
+}
+
+// param returns a parameter of a control sequence, or the default if it is
+// missing or zero.
+func param(ints []int, i, dflt int) int {
+	if i < len(ints) && ints[i] != 0 {
+		return ints[i]
+	}
+	return dflt
+}
+
+func (p *parser) csiDispatch(b byte) {
+	h := p.handler
+	ints := p.ints()
+	if len(p.inter) > 0 {
+		if string(p.inter) == " " && b == 'q' && p.prefix == 0 {
+			h.DECSCUSR(param(ints, 0, 0))
+		}
+		return
+	}
+	if p.prefix != 0 {
+		switch {
+		case p.prefix == '?' && (b == 'h' || b == 'l'):
+			for _, mode := range ints {
+				h.privateMode(mode, b == 'h')
+			}
+		case b == 'c':
+			h.DA(p.prefix, ints)
+		case b == 'n':
+			h.DSR(p.prefix, param(ints, 0, 0))
+		}
+		return
+	}
+	switch b {
+	case '@':
+		h.ICH(param(ints, 0, 1))
+	case 'A':
+		h.CUU(param(ints, 0, 1))
+	case 'B':
+		h.CUD(param(ints, 0, 1))
+	case 'C':
+		h.CUF(param(ints, 0, 1))
+	case 'D':
+		h.CUB(param(ints, 0, 1))
+	case 'E':
+		h.CNL(param(ints, 0, 1))
+	case 'F':
+		h.CPL(param(ints, 0, 1))
+	case 'G', '`':
+		h.CHA(param(ints, 0, 1))
+	case 'H':
+		h.CUP(param(ints, 0, 1), param(ints, 1, 1))
+	case 'J':
+		h.ED(param(ints, 0, 0))
+	case 'K':
+		h.EL(param(ints, 0, 0))
+	case 'L':
+		h.IL(param(ints, 0, 1))
+	case 'M':
+		h.DL(param(ints, 0, 1))
+	case 'P':
+		h.DCH(param(ints, 0, 1))
+	case 'S':
+		h.SU(param(ints, 0, 1))
+	case 'T':
+		h.SD(param(ints, 0, 1))
+	case 'X':
+		h.ECH(param(ints, 0, 1))
+	case 'b':
+		h.REP(param(ints, 0, 1))
+	case 'c':
+		h.DA(0, ints)
+	case 'd':
+		h.VPA(param(ints, 0, 1))
+	case 'f':
+		h.HVP(param(ints, 0, 1), param(ints, 1, 1))
+	case 'm':
+		h.SGR(parseParams(p.params))
+	case 'n':
+		h.DSR(0, param(ints, 0, 0))
+	case 'r':
+		h.DECSTBM(param(ints, 0, 1), param(ints, 1, 0))
+	case 't':
+		h.XTWINOPS(ints)
+	case 's':
+		h.DECSC()
+	case 'u':
+		h.DECRC()
+	}
+}
@@ -0,0 +1,170 @@
+package vtio
+
+import (
+	"image/color"
+
+	"github.com/kriskowal/cops/display"
+)
+
+// pen tracks the graphic rendition that select graphic rendition (SGR)
+// sequences set for subsequent text.
+// A nil color stands for the default color of whatever the pen draws on.
+type pen struct {
+	fg   color.Color
+	bg   color.Color
+	attr display.Attr
+	// low is the index of the foreground color, plus one, if it is one of
+	// the first eight palette colors, which bold text brightens.
+	low int
+}
+
+// sgr applies the parameters of a select graphic rendition sequence.
+// Each parameter is a code and any subparameters that followed it, separated
+// by colons, as in 38:2::255:128:0.
+// No parameters at all is equivalent to the reset code 0.
+func (p *pen) sgr(params [][]int) {
+	if len(params) == 0 {
+		*p = pen{}
+	}
+
+	for len(params) > 0 {
+		param := params[0]
+		params = params[1:]
+		code := param[0]
+		sub := param[1:]
+		switch {
+
+		case code == 0: // reset
+			*p = pen{}
+
+		case code == 1:
+			p.attr |= display.Bold
+		case code == 2:
+			p.attr |= display.Dim
+		case code == 3:
+			p.attr |= display.Italic
+		case code == 4:
+			// Underline styles, like 4:3 for curly, are all underlines, but
+			// 4:0 is none.
+			if len(sub) > 0 && sub[0] == 0 {
+				p.attr &^= display.Underline
+			} else {
+				p.attr |= display.Underline
+			}
+		case code == 5 || code == 6:
+			p.attr |= display.Blink
+		case code == 7:
+			p.attr |= display.Reverse
+		case code == 9:
+			p.attr |= display.Strikethrough
+		case code == 21: // double underline
+			p.attr |= display.Underline
+		case code == 22:
+			p.attr &^= display.Bold | display.Dim
+		case code == 23:
+			p.attr &^= display.Italic
+		case code == 24:
+			p.attr &^= display.Underline
+		case code == 25:
+			p.attr &^= display.Blink
+		case code == 27:
+			p.attr &^= display.Reverse
+		case code == 29:
+			p.attr &^= display.Strikethrough
+
+		case code >= 30 && code < 38: // set foreground color
+			p.fg = display.Colors[code-30]
+			p.low = code - 30 + 1
+		case code >= 90 && code < 98: // set high intensity foreground color
+			p.fg = display.Colors[code-90+8]
+			p.low = 0
+		case code == 39:
+			p.fg = nil
+			p.low = 0
+		case code == 38: // set foreground color
+			p.fg, params = extendedColor(sub, params)
+			p.low = 0
+
+		case code >= 40 && code < 48: // set background color
+			p.bg = display.Colors[code-40]
+		case code >= 100 && code < 108: // set high intensity background color
+			p.bg = display.Colors[code-100+8]
+		case code == 48: // set background color
+			p.bg, params = extendedColor(sub, params)
+		case code == 49:
+			p.bg = nil
+
+		case code == 58: // underline color, which displays do not model
+			_, params = extendedColor(sub, params)
+		}
+	}
+}
+
+// colors returns the foreground and background colors of the pen, falling
+// back to the given defaults.
+// Like xterm, bold text in one of the first eight palette colors takes the
+// bright variant of the color.
+func (p pen) colors(fg, bg color.Color) (color.Color, color.Color) {
+	if p.fg != nil {
+		fg = p.fg
+	}
+	if p.low > 0 && p.attr&display.Bold != 0 {
+		fg = display.Colors[p.low-1+8]
+	}
+	if p.bg != nil {
+		bg = p.bg
+	}
+	return fg, bg
+}
+
+// extendedColor reads the color of a 38 or 48 code, either from its colon
+// separated subparameters, like 38:5:208 or 38:2::255:128:0, or from the
+// semicolon separated parameters that follow it, like 38;5;208 or
+// 38;2;255;128;0, returning the remaining parameters.
+func extendedColor(sub []int, params [][]int) (color.RGBA, [][]int) {
+	if len(sub) > 0 {
+		if sub[0] == 2 && len(sub) >= 5 {
+			// The color space identifier before the components is optional.
+			sub = sub[len(sub)-3:]
+			return color.RGBA{byte(sub[0]), byte(sub[1]), byte(sub[2]), 255}, params
+		}
+		c, _ := colorForCodes(sub)
+		return c, params
+	}
+	codes := make([]int, 0, 4)
+	for _, param := range params {
+		if len(codes) == 4 {
+			break
+		}
+		codes = append(codes, param[0])
+	}
+	c, rest := colorForCodes(codes)
+	return c, params[len(codes)-len(rest):]
+}
+
+func colorForCodes(codes []int) (color.RGBA, []int) {
+	if len(codes) == 0 {
+		return display.Colors[0], codes
+	}
+	code := codes[0]
+	codes = codes[1:]
+	switch {
+	case code == 5:
+		if len(codes) < 1 {
+			return display.Colors[0], codes
+		}
+		return display.Colors[byte(codes[0])], codes[1:]
+	case code == 2:
+		if len(codes) < 3 {
+			return display.Colors[0], codes
+		}
+		return color.RGBA{
+			byte(codes[0]),
+			byte(codes[1]),
+			byte(codes[2]),
+			255,
+		}, codes[3:]
+	}
+
+	return display.Colors[0], codes
+}
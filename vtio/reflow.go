@@ -0,0 +1,162 @@
+package vtio
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/textile"
+)
+
+// cell is the content of one cell of a display, exactly.
+type cell struct {
+	text   string
+	fg, bg color.RGBA
+	attr   display.Attr
+}
+
+// blank returns whether a cell shows nothing, either because nothing was
+// ever written to it or because it was erased with the default background.
+func (c cell) blank() bool {
+	if c.text != "" && c.text != " " {
+		return false
+	}
+	return c.attr == 0 && (c.bg.A == 0 || c.bg == display.Colors[0])
+}
+
+// readRow returns the cells of a row of a display.
+func readRow(dis *display.Display, y int) []cell {
+	row := make([]cell, 0, dis.Rect.Dx())
+	for x := dis.Rect.Min.X; x < dis.Rect.Max.X; x++ {
+		row = append(row, cell{
+			text: dis.Text.At(x, y),
+			fg:   dis.Foreground.RGBAAt(x, y),
+			bg:   dis.Background.RGBAAt(x, y),
+			attr: dis.Attributes.At(x, y),
+		})
+	}
+	return row
+}
+
+// writeRow writes cells to a row of a display, as far as they fit.
+func writeRow(dis *display.Display, y int, row []cell) {
+	for i, c := range row {
+		x := dis.Rect.Min.X + i
+		if x >= dis.Rect.Max.X {
+			break
+		}
+		dis.Text.Set(x, y, c.text)
+		dis.Foreground.SetRGBA(x, y, c.fg)
+		dis.Background.SetRGBA(x, y, c.bg)
+		dis.Attributes.Set(x, y, c.attr)
+	}
+}
+
+// reflow returns a display of a new size with the lines of the scrollback
+// and of the given normal screen wrapped anew to its width, along with the
+// soft-wrapped rows of the new display and the new position of the cursor,
+// which stays with the character it was on.
+// The scrollback receives the rows that do not fit on the new display above
+// the cursor.
+func (h *displayWriterHandler) reflow(old *display.Display, wrapped []bool, pos image.Point, rect image.Rectangle) (*display.Display, []bool, image.Point) {
+	dis := display.New(rect)
+	soft := make([]bool, rect.Dy())
+	width, height := rect.Dx(), rect.Dy()
+	if width <= 0 || height <= 0 {
+		return dis, soft, rect.Min
+	}
+
+	// Join soft-wrapped rows into lines, finding the line and offset of the
+	// cursor.
+	var lines [][]cell
+	var line []cell
+	cursorLine, cursorOffset := 0, 0
+	join := func(row []cell, wrapped bool) {
+		if wrapped {
+			// A wide character that did not fit may have skipped the last
+			// cell.
+			for len(row) > 0 && row[len(row)-1].text == "" {
+				row = row[:len(row)-1]
+			}
+			line = append(line, row...)
+			return
+		}
+		line = append(line, row...)
+		for len(line) > 0 && line[len(line)-1].blank() {
+			line = line[:len(line)-1]
+		}
+		lines = append(lines, line)
+		line = nil
+	}
+	for i := 0; i < h.history.count; i++ {
+		l := h.history.at(i)
+		join(readRow(l.Display, l.Rect.Min.Y), l.wrapped)
+	}
+	for y := old.Rect.Min.Y; y < old.Rect.Max.Y; y++ {
+		if y == pos.Y {
+			cursorLine, cursorOffset = len(lines), len(line)+pos.X-old.Rect.Min.X
+		}
+		join(readRow(old, y), wrapped[y-old.Rect.Min.Y] && y < old.Rect.Max.Y-1)
+	}
+	// Blank lines below the cursor would only push the rest up.
+	for len(lines) > cursorLine+1 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	// Wrap the lines to the new width, keeping wide characters whole.
+	var rows [][]cell
+	var rowWrapped []bool
+	var cursor image.Point
+	for i, line := range lines {
+		var row []cell
+		x := 0
+		for j := 0; j < len(line); {
+			n := 1
+			if j+1 < len(line) && line[j+1].text == textile.Continuation {
+				n = 2
+			}
+			if x+n > width && x > 0 {
+				rows, rowWrapped = append(rows, row), append(rowWrapped, true)
+				row, x = nil, 0
+			}
+			if i == cursorLine && cursorOffset >= j && cursorOffset < j+n {
+				cursor = image.Pt(x+cursorOffset-j, len(rows))
+			}
+			row = append(row, line[j:j+n]...)
+			x += n
+			j += n
+		}
+		if i == cursorLine && cursorOffset >= len(line) {
+			// The cursor is beyond the end of the text of its line.
+			col := x + cursorOffset - len(line)
+			for col >= width {
+				rows, rowWrapped = append(rows, row), append(rowWrapped, true)
+				row, col = nil, col-width
+			}
+			cursor = image.Pt(col, len(rows))
+		}
+		rows, rowWrapped = append(rows, row), append(rowWrapped, false)
+	}
+
+	// Show the bottom rows, or the rows from the cursor down if the cursor
+	// would otherwise fall above the top, and return the rest to the
+	// scrollback.
+	start := len(rows) - height
+	if start > cursor.Y {
+		start = cursor.Y
+	}
+	if start < 0 {
+		start = 0
+	}
+	h.history.clear()
+	for i := 0; i < start; i++ {
+		l := display.New(image.Rect(0, 0, width, 1))
+		writeRow(l, 0, rows[i])
+		h.history.push(l, rowWrapped[i])
+	}
+	for j := 0; j < height && start+j < len(rows); j++ {
+		writeRow(dis, rect.Min.Y+j, rows[start+j])
+		soft[j] = rowWrapped[start+j]
+	}
+	return dis, soft, rect.Min.Add(cursor).Sub(image.Pt(0, start))
+}
@@ -0,0 +1,77 @@
+package vtio
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPendingWrap(t *testing.T) {
+	// The cursor waits in the last column, so moving back one column lands
+	// on the second to last.
+	assert.Equal(t, []string{"axc", "..."}, screen(3, 2, "abc\033[Dx"))
+	// A carriage return cancels the pending wrap.
+	assert.Equal(t, []string{"xbc", "..."}, screen(3, 2, "abc\rx"))
+	assert.Equal(t, []string{"abc", "d.."}, screen(3, 2, "abcd"))
+
+	vtw := NewDisplayWriter(image.Rect(0, 0, 3, 2))
+	vtw.Write([]byte("abc"))
+	assert.Equal(t, image.Pt(2, 0), vtw.Cursor().Position)
+}
+
+func TestAutowrapMode(t *testing.T) {
+	assert.Equal(t, []string{"abe", "..."}, screen(3, 2, "\033[?7labcde"))
+	assert.Equal(t, []string{"abc", "d.."}, screen(3, 2, "\033[?7l\033[?7habcd"))
+}
+
+func TestSoftWrappedRows(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 3, 3))
+	vtw.Write([]byte("abcdef\r\ngh"))
+	assert.Equal(t, []bool{true, false, false}, vtw.handler.wrapped)
+	vtw.Write([]byte("\r\n\n"))
+	assert.Equal(t, []bool{false, false, false}, vtw.handler.wrapped)
+	assert.True(t, vtw.handler.history.at(0).wrapped)
+}
+
+func TestReflowWider(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 3, 3))
+	vtw.Write([]byte("abcdef\r\ngh"))
+	vtw.Resize(image.Rect(0, 0, 6, 3))
+	assert.Equal(t, []string{"abcdef", "gh....", "......"}, rows(vtw))
+	assert.Equal(t, image.Pt(2, 1), vtw.Cursor().Position)
+	vtw.Write([]byte("i"))
+	assert.Equal(t, []string{"abcdef", "ghi...", "......"}, rows(vtw))
+}
+
+func TestReflowNarrower(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 6, 2))
+	vtw.Write([]byte("abcdef\r\ngh"))
+	vtw.Resize(image.Rect(0, 0, 3, 2))
+	assert.Equal(t, []string{"def", "gh."}, rows(vtw))
+	assert.Equal(t, 1, vtw.Scrollback())
+	assert.Equal(t, "abc", vtw.Line(0))
+	assert.Equal(t, image.Pt(2, 1), vtw.Cursor().Position)
+}
+
+func TestReflowScrollback(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 3, 2))
+	vtw.Write([]byte("abcdefghi"))
+	assert.Equal(t, 1, vtw.Scrollback())
+	vtw.Resize(image.Rect(0, 0, 9, 2))
+	assert.Equal(t, 0, vtw.Scrollback())
+	assert.Equal(t, []string{"abcdefghi", "........."}, rows(vtw))
+	assert.Equal(t, image.Pt(8, 0), vtw.Cursor().Position)
+}
+
+func TestReflowAlternateScreen(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 3, 2))
+	vtw.Write([]byte("abcd\033[?1049hxy"))
+	vtw.Resize(image.Rect(0, 0, 6, 2))
+	// The alternate screen keeps its rows, while the normal screen reflows
+	// behind it, along with the cursor saved on entering it.
+	assert.Equal(t, []string{"   ...", " xy..."}, rows(vtw))
+	vtw.Write([]byte("\033[?1049l"))
+	assert.Equal(t, []string{"abcd..", "......"}, rows(vtw))
+	assert.Equal(t, image.Pt(4, 0), vtw.Cursor().Position)
+}
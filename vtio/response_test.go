@@ -0,0 +1,53 @@
+package vtio
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponses(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 80, 24))
+	var out bytes.Buffer
+	vtw.SetResponseWriter(&out)
+	for _, c := range []struct{ query, response string }{
+		{"\033[c", "\033[?62;22c"},
+		{"\033[0c", "\033[?62;22c"},
+		{"\033[>c", "\033[>1;10;0c"},
+		{"\033[5n", "\033[0n"},
+		{"\033[3;7H\033[6n", "\033[3;7R"},
+		{"\033[?6n", "\033[?3;7R"},
+		{"\033[18t", "\033[8;24;80t"},
+		{"\033[14t", ""},
+	} {
+		out.Reset()
+		_, err := vtw.Write([]byte(c.query))
+		assert.NoError(t, err)
+		assert.Equal(t, c.response, out.String(), "%q", c.query)
+	}
+}
+
+func TestNoResponseWriter(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 80, 24))
+	_, err := vtw.Write([]byte("\033[6n"))
+	assert.NoError(t, err)
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errors.New("closed")
+}
+
+func TestResponseError(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 80, 24))
+	vtw.SetResponseWriter(failWriter{})
+	_, err := vtw.Write([]byte("\033[6nabc"))
+	assert.EqualError(t, err, "closed")
+	assert.Equal(t, "abc", vtw.Line(0), "continues interpreting output")
+	_, err = vtw.Write([]byte("def"))
+	assert.NoError(t, err)
+}
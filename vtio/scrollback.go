@@ -0,0 +1,156 @@
+package vtio
+
+import (
+	"image"
+	"image/draw"
+	"strings"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/textile"
+)
+
+// DefaultScrollback is the number of lines that scroll off the top of a
+// display writer that it retains, until SetScrollback changes it.
+const DefaultScrollback = 1000
+
+// scrollback is a bounded ring of lines that scrolled off the top of the
+// display, each a display one row high.
+// When the ring is full, each new line replaces the oldest.
+type scrollback struct {
+	lines []historyLine
+	start int
+	count int
+}
+
+// historyLine is a line of scrollback and whether it was soft-wrapped, that
+// is, whether the text of the line continues on the next.
+type historyLine struct {
+	*display.Display
+	wrapped bool
+}
+
+func (s *scrollback) push(line *display.Display, wrapped bool) {
+	if len(s.lines) == 0 {
+		return
+	}
+	if s.count < len(s.lines) {
+		s.lines[(s.start+s.count)%len(s.lines)] = historyLine{line, wrapped}
+		s.count++
+		return
+	}
+	s.lines[s.start] = historyLine{line, wrapped}
+	s.start = (s.start + 1) % len(s.lines)
+}
+
+// at returns the line at an index, from 0 for the oldest line.
+func (s *scrollback) at(i int) historyLine {
+	return s.lines[(s.start+i)%len(s.lines)]
+}
+
+// resize changes the capacity of the ring, retaining the newest lines.
+func (s *scrollback) resize(n int) {
+	if n < 0 {
+		n = 0
+	}
+	lines := make([]historyLine, n)
+	count := s.count
+	if count > n {
+		count = n
+	}
+	for i := 0; i < count; i++ {
+		lines[i] = s.at(s.count - count + i)
+	}
+	*s = scrollback{lines: lines, count: count}
+}
+
+func (s *scrollback) clear() {
+	*s = scrollback{lines: make([]historyLine, len(s.lines))}
+}
+
+// SetScrollback changes the number of lines of scrollback the display writer
+// retains, discarding the oldest lines if there are more.
+// Zero, or less, disables scrollback.
+func (d *DisplayWriter) SetScrollback(lines int) {
+	d.handler.lock.Lock()
+	defer d.handler.lock.Unlock()
+	d.handler.history.resize(lines)
+}
+
+// Scrollback returns the number of lines of scrollback, the lines that have
+// scrolled off the top of the display.
+func (d *DisplayWriter) Scrollback() int {
+	d.handler.lock.RLock()
+	defer d.handler.lock.RUnlock()
+	return d.handler.history.count
+}
+
+// Lines returns the total number of lines, the lines of scrollback followed
+// by the rows of the display.
+func (d *DisplayWriter) Lines() int {
+	d.handler.lock.RLock()
+	defer d.handler.lock.RUnlock()
+	return d.handler.history.count + d.handler.rect.Dy()
+}
+
+// Line returns the text of a line, numbering the lines of scrollback from 0
+// for the oldest, followed by the rows of the display, for searching.
+// Empty cells read as spaces, and Line trims trailing spaces.
+func (d *DisplayWriter) Line(i int) string {
+	d.handler.lock.RLock()
+	defer d.handler.lock.RUnlock()
+	line, y := d.handler.line(i)
+	if line == nil {
+		return ""
+	}
+	var s strings.Builder
+	for x := line.Rect.Min.X; x < line.Rect.Max.X; x++ {
+		t := line.Text.At(x, y)
+		switch t {
+		case "":
+			s.WriteByte(' ')
+		case textile.Continuation:
+		default:
+			s.WriteString(t)
+		}
+	}
+	return strings.TrimRight(s.String(), " ")
+}
+
+// DrawScrollback draws a viewport of the display writer's lines onto a
+// display, scrolled back from the bottom by the given number of lines.
+// At offset 0, the viewport shows the bottom of the display, just as Draw
+// does, and at an offset of Scrollback(), with a viewport as high as the
+// display, it shows the oldest lines of scrollback.
+// The offset is clamped to that range.
+//
+//	// Scroll back a page.
+//	offset += bounds.Dy()
+//	vtw.DrawScrollback(front, bounds, offset)
+func (d *DisplayWriter) DrawScrollback(e *display.Display, r image.Rectangle, offset int) {
+	d.handler.lock.RLock()
+	defer d.handler.lock.RUnlock()
+	h := d.handler
+	offset = clamp(offset, 0, h.history.count)
+	first := h.history.count + h.rect.Dy() - r.Dy() - offset
+	for j := 0; j < r.Dy(); j++ {
+		line, y := h.line(first + j)
+		if line == nil {
+			continue
+		}
+		row := image.Rect(r.Min.X, r.Min.Y+j, r.Max.X, r.Min.Y+j+1)
+		display.Draw(e, row, line, image.Pt(line.Rect.Min.X, y), draw.Src)
+	}
+}
+
+// line returns the display that holds a line, numbered from the oldest line
+// of scrollback, and the row of the line within that display, or nil if
+// there is no such line.
+func (h *displayWriterHandler) line(i int) (*display.Display, int) {
+	if i < 0 || i >= h.history.count+h.rect.Dy() {
+		return nil, 0
+	}
+	if i < h.history.count {
+		return h.history.at(i).Display, 0
+	}
+	return h.dis, h.rect.Min.Y + i - h.history.count
+}
@@ -0,0 +1,83 @@
+package vtio
+
+import (
+	"image"
+	"testing"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrollback(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 3, 2))
+	vtw.Write([]byte("111\r\n222\r\n333\r\n444"))
+	assert.Equal(t, 2, vtw.Scrollback())
+	assert.Equal(t, 4, vtw.Lines())
+	var lines []string
+	for i := 0; i < vtw.Lines(); i++ {
+		lines = append(lines, vtw.Line(i))
+	}
+	assert.Equal(t, []string{"111", "222", "333", "444"}, lines)
+	assert.Equal(t, "", vtw.Line(4))
+}
+
+func TestScrollbackLimit(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 1, 1))
+	vtw.SetScrollback(2)
+	vtw.Write([]byte("1\r\n2\r\n3\r\n4"))
+	assert.Equal(t, 2, vtw.Scrollback())
+	assert.Equal(t, "2", vtw.Line(0))
+	assert.Equal(t, "3", vtw.Line(1))
+	assert.Equal(t, "4", vtw.Line(2))
+
+	vtw.SetScrollback(1)
+	assert.Equal(t, "3", vtw.Line(0))
+
+	vtw.SetScrollback(0)
+	vtw.Write([]byte("\r\n5"))
+	assert.Equal(t, 0, vtw.Scrollback())
+
+	vtw.SetScrollback(-1)
+	vtw.Write([]byte("\r\n6"))
+	assert.Equal(t, 0, vtw.Scrollback())
+	assert.Equal(t, "6", vtw.Line(0))
+}
+
+func TestScrollbackOnlyFromTop(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 3, 3))
+	// Scrolling a region below the top margin discards lines.
+	vtw.Write([]byte("\033[2;3r\033[2H111\r\n222\r\n333"))
+	assert.Equal(t, 0, vtw.Scrollback())
+	// Erase scrollback.
+	vtw.Write([]byte("\033[r\033[3H\n\n\033[3J"))
+	assert.Equal(t, 0, vtw.Scrollback())
+}
+
+func TestScrollbackNotFromDeleteLine(t *testing.T) {
+	// Deleted lines are gone, and inserted lines push lines off the bottom.
+	vtw := NewDisplayWriter(image.Rect(0, 0, 1, 2))
+	vtw.Write([]byte("a\r\nb\033[H\033[M"))
+	assert.Equal(t, 0, vtw.Scrollback())
+	assert.Equal(t, "b", vtw.Line(0))
+
+	vtw.Write([]byte("\033[L\033[L"))
+	assert.Equal(t, 0, vtw.Scrollback())
+	assert.Equal(t, "", vtw.Line(0))
+}
+
+func TestDrawScrollback(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 3, 2))
+	vtw.Write([]byte("\033[31m111\r\n222\r\n333\r\n444"))
+	view := display.New(image.Rect(0, 0, 3, 2))
+
+	vtw.DrawScrollback(view, view.Bounds(), 1)
+	assert.Equal(t, []string{"2", "2", "2", "3", "3", "3"}, view.Text.Strings)
+	_, f, _ := view.At(0, 0)
+	assert.Equal(t, display.Colors[1], f)
+
+	vtw.DrawScrollback(view, view.Bounds(), 99)
+	assert.Equal(t, []string{"1", "1", "1", "2", "2", "2"}, view.Text.Strings)
+
+	vtw.DrawScrollback(view, view.Bounds(), 0)
+	assert.Equal(t, []string{"3", "3", "3", "4", "4", "4"}, view.Text.Strings)
+}
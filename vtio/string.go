@@ -0,0 +1,153 @@
+package vtio
+
+import (
+	"image"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/textile"
+)
+
+const tabStopWidth = 8
+
+// WriteString draws a string with ANSI select graphic rendition (SGR) escape
+// sequences onto a display within the given bounds, as output from another
+// program that colors its text, without the goroutine and channel of a
+// DisplayWriter.
+//
+//	out, _ := exec.Command("ls", "--color=always").Output()
+//	vtio.WriteString(panel, panel.Bounds(), string(out))
+//
+// WriteString follows SGR colors and attributes but otherwise executes only the subset of the
+// terminal language that the "text" package does: a newline advances to the
+// first column of the next line, tab advances to the next tab stop, and
+// carriage return and backspace move within the line.
+// It ignores other escape sequences, including cursor movement.
+//
+// Like the "text" package, WriteString treats white space as transparent,
+// unless an SGR sequence has set a background color, in which case it fills
+// the background of each space it passes.
+// Text without a foreground color takes the default white (7).
+// WriteString clips text to the bounds and omits wide characters that would
+// straddle the right edge.
+func WriteString(dst *display.Display, bounds image.Rectangle, str string) {
+	scanString(str, func(pt image.Point, c string, w int, p pen) {
+		pt = pt.Add(bounds.Min)
+		end := pt.Add(image.Pt(w-1, 0))
+		if !pt.In(bounds) || !end.In(bounds) {
+			return
+		}
+		fg, bg := p.colors(display.Colors[7], nil)
+		if bg != nil {
+			for x := pt.X; x <= end.X; x++ {
+				dst.Background.Set(x, pt.Y, bg)
+			}
+		}
+		if c == " " {
+			return
+		}
+		dst.Text.Set(pt.X, pt.Y, c)
+		dst.Foreground.Set(pt.X, pt.Y, fg)
+		dst.Attributes.Set(pt.X, pt.Y, p.attr)
+		if w == 2 {
+			dst.Text.Set(end.X, end.Y, textile.Continuation)
+			dst.Foreground.Set(end.X, end.Y, fg)
+			dst.Attributes.Set(end.X, end.Y, p.attr)
+		}
+	})
+}
+
+// StringBounds measures a bounding box that would hold the given string,
+// disregarding its escape sequences.
+func StringBounds(str string) image.Rectangle {
+	var bounds image.Rectangle
+	scanString(str, func(pt image.Point, c string, w int, p pen) {
+		if c == " " && p.bg == nil {
+			return
+		}
+		bounds = bounds.Union(image.Rect(pt.X, pt.Y, pt.X+w, pt.Y+1))
+	})
+	return image.Rect(0, 0, bounds.Max.X, bounds.Max.Y)
+}
+
+// scanString calls draw with the position, text, width and pen of every glyph
+// and space of a string, following SGR sequences and the control characters
+// that move within the text.
+func scanString(str string, draw func(pt image.Point, c string, w int, p pen)) {
+	var p pen
+	var pt image.Point
+	for str != "" {
+		switch str[0] {
+		case '\033':
+			str = escapeString(str, &p)
+			continue
+		case '\n':
+			pt = image.Pt(0, pt.Y+1)
+		case '\r':
+			pt.X = 0
+		case '\b':
+			if pt.X > 0 {
+				pt.X--
+			}
+		case '\t':
+			x := (pt.X + tabStopWidth) / tabStopWidth * tabStopWidth
+			for ; pt.X < x; pt.X++ {
+				draw(pt, " ", 1, p)
+			}
+		case ' ':
+			draw(pt, " ", 1, p)
+			pt.X++
+		default:
+			c, w := textile.Cluster(str)
+			if w > 0 {
+				draw(pt, c, w, p)
+				pt.X += w
+			}
+			str = str[len(c):]
+			continue
+		}
+		str = str[1:]
+	}
+}
+
+// escapeString consumes the escape sequence at the beginning of a string,
+// applying it to the pen if it is an SGR sequence, and returns the remainder.
+func escapeString(str string, p *pen) string {
+	if len(str) < 2 {
+		return ""
+	}
+	switch str[1] {
+	case '[':
+		// Control sequence: parameters and intermediates, then a final byte.
+		for i := 2; i < len(str); i++ {
+			if str[i] >= 0x40 && str[i] <= 0x7e {
+				if str[i] == 'm' {
+					p.sgr(parseParams([]byte(str[2:i])))
+				}
+				return str[i+1:]
+			}
+		}
+		return ""
+	case ']', 'P', '_', '^':
+		// Operating system command or other string, terminated by bell or
+		// string terminator.
+		for i := 2; i < len(str); i++ {
+			if str[i] == '\a' {
+				return str[i+1:]
+			}
+			if str[i] == '\033' && i+1 < len(str) && str[i+1] == '\\' {
+				return str[i+2:]
+			}
+		}
+		return ""
+	}
+	// Escape sequence: intermediates, like the ( of ESC ( B, which designates
+	// a character set, then a final byte.
+	i := 1
+	for i < len(str) && str[i] >= 0x20 && str[i] <= 0x2f {
+		i++
+	}
+	if i < len(str) && str[i] >= 0x30 && str[i] <= 0x7e {
+		i++
+	}
+	return str[i:]
+}
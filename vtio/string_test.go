@@ -0,0 +1,62 @@
+package vtio
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/textile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteString(t *testing.T) {
+	bounds := image.Rect(0, 0, 6, 2)
+	d := display.New(bounds)
+	WriteString(d, bounds, "\033[31mab\033[0m c\n\033[38;2;1;2;3;44mx y\033[m")
+
+	assert.Equal(t, []string{"a", "b", "", "c", "", ""}, d.Text.Strings[0:6])
+	_, f, _ := d.At(0, 0)
+	assert.Equal(t, display.Colors[1], f)
+	_, f, _ = d.At(3, 0)
+	assert.Equal(t, display.Colors[7], f)
+
+	assert.Equal(t, []string{"x", "", "y", "", "", ""}, d.Text.Strings[6:12])
+	_, f, b := d.At(0, 1)
+	assert.Equal(t, color.RGBA{1, 2, 3, 255}, f)
+	assert.Equal(t, display.Colors[4], b)
+	_, _, b = d.At(1, 1)
+	assert.Equal(t, display.Colors[4], b, "space takes the background")
+	_, _, b = d.At(3, 1)
+	assert.Equal(t, color.RGBA{}, b, "background stops after reset")
+}
+
+func TestWriteStringIgnoresOtherEscapes(t *testing.T) {
+	bounds := image.Rect(0, 0, 4, 1)
+	d := display.New(bounds)
+	WriteString(d, bounds, "\033]0;title\a\033[2Ka\033[1Cb\033=c")
+	assert.Equal(t, []string{"a", "b", "c", ""}, d.Text.Strings)
+}
+
+func TestWriteStringIgnoresCharacterSets(t *testing.T) {
+	// Terminfo's sgr0 for xterm ends bold with ESC ( B.
+	bounds := image.Rect(0, 0, 4, 1)
+	d := display.New(bounds)
+	WriteString(d, bounds, "\033[1mhi\033(B\033[mX\033)0Y")
+	assert.Equal(t, []string{"h", "i", "X", "Y"}, d.Text.Strings)
+}
+
+func TestWriteStringClips(t *testing.T) {
+	bounds := image.Rect(0, 0, 3, 1)
+	d := display.New(bounds)
+	WriteString(d, image.Rect(1, 0, 3, 1), "a世b")
+	assert.Equal(t, []string{"", "a", ""}, d.Text.Strings)
+
+	WriteString(d, bounds, "\033[32m世")
+	assert.Equal(t, []string{"世", textile.Continuation, ""}, d.Text.Strings)
+}
+
+func TestStringBounds(t *testing.T) {
+	assert.Equal(t, image.Rect(0, 0, 5, 2), StringBounds("\033[1;31mhello\033[0m\nworld  "))
+	assert.Equal(t, image.Rect(0, 0, 9, 1), StringBounds("\033[41mab\tc"))
+}
@@ -1,34 +1,38 @@
 package vtio
 
 import (
-	// "fmt"
-
+	"fmt"
 	"image"
-	"image/color"
 	"image/draw"
+	"io"
 	"sync"
+	"unicode/utf8"
 
-	ansiterm "github.com/Azure/go-ansiterm"
 	"github.com/kriskowal/cops/display"
+	"github.com/kriskowal/cops/textile"
 )
 
 func NewDisplayWriter(rect image.Rectangle) *DisplayWriter {
 	dis := display.New(rect)
 	handler := &displayWriterHandler{
-		dis: dis,
-		fg:  display.Colors[7],
-		bg:  display.Colors[0],
-		c:   make(chan struct{}, 1),
+		dis:     dis,
+		rect:    rect,
+		top:     rect.Min.Y,
+		bottom:  rect.Max.Y,
+		wrapped: make([]bool, rect.Dy()),
+		c:       make(chan struct{}, 1),
+		history: scrollback{
+			lines: make([]historyLine, DefaultScrollback),
+		},
 	}
-	par := ansiterm.CreateParser("Ground", handler)
 	return &DisplayWriter{
-		parser:  par,
+		parser:  &parser{handler: handler},
 		handler: handler,
 	}
 }
 
 type DisplayWriter struct {
-	parser  *ansiterm.AnsiParser
+	parser  *parser
 	handler *displayWriterHandler
 }
 
@@ -41,6 +45,13 @@ func (d *DisplayWriter) Write(buf []byte) (int, error) {
 	defer d.handler.lock.Unlock()
 
 	count, err := d.parser.Parse(buf)
+	if err == nil {
+		err = d.handler.Flush()
+	}
+	if err == nil {
+		err = d.handler.err
+		d.handler.err = nil
+	}
 
 	select {
 	case d.handler.c <- struct{}{}:
@@ -50,19 +61,68 @@ func (d *DisplayWriter) Write(buf []byte) (int, error) {
 	return count, err
 }
 
+// SetResponseWriter directs the display writer to answer the queries of the
+// program it displays, like requests for its device attributes, cursor
+// position, or size, typically by writing back to the leader of the program's
+// pseudo-terminal.
+// Programs like fzf wait for the answers and hang without them.
+// Write returns the first error writing a response.
+//
+// The display writer writes responses while interpreting the program's
+// output, so the response writer must not block waiting for the display
+// writer.
+//
+//	vtw := vtio.NewDisplayWriter(bounds)
+//	vtw.SetResponseWriter(leader)
+//	go io.Copy(vtw, leader)
+func (d *DisplayWriter) SetResponseWriter(w io.Writer) {
+	d.handler.lock.Lock()
+	defer d.handler.lock.Unlock()
+	d.handler.responses = w
+}
+
 func (d *DisplayWriter) Draw(e *display.Display, r image.Rectangle) {
 	d.handler.lock.RLock()
 	defer d.handler.lock.RUnlock()
 	display.Draw(e, r, d.handler.dis, image.ZP, draw.Src)
 }
 
+// Resize changes the size of the display writer's screen.
+// The lines of the normal screen and its scrollback reflow to the new width,
+// joining the rows that wrapped for lack of space and wrapping them anew, as
+// terminal emulators do.
+// The alternate screen does not reflow, since the programs that use it
+// redraw when their terminal changes size.
 func (d *DisplayWriter) Resize(rect image.Rectangle) {
 	d.handler.lock.Lock()
 	defer d.handler.lock.Unlock()
+	h := d.handler
+	if h.primary == nil {
+		h.dis, h.wrapped, h.pos = h.reflow(h.dis, h.wrapped, h.pos, rect)
+	} else {
+		pos := h.dis.Rect.Min
+		saved := h.saved[0]
+		if saved != nil {
+			pos = saved.pos
+		}
+		h.primary, h.primaryWrapped, pos = h.reflow(h.primary, h.primaryWrapped, pos, rect)
+		if saved != nil {
+			saved.pos = pos
+		}
+		h.dis = resize(h.dis, rect)
+		h.wrapped = make([]bool, rect.Dy())
+	}
+	h.rect = rect
+	h.top = rect.Min.Y
+	h.bottom = rect.Max.Y
+	h.clamp()
+}
+
+// resize returns a display of a new size with the content of another.
+func resize(old *display.Display, rect image.Rectangle) *display.Display {
 	dis := display.New(rect)
-	display.Draw(dis, rect, d.handler.dis, image.ZP, draw.Src)
-	d.handler.dis = dis
-	d.handler.rect = rect
+	display.Draw(dis, rect, old, image.ZP, draw.Src)
+	return dis
 }
 
 type displayWriterHandler struct {
@@ -71,32 +131,217 @@ type displayWriterHandler struct {
 	dis  *display.Display
 	pos  image.Point
 	rect image.Rectangle
-	fg   color.Color
-	bg   color.Color
-	buf  []byte
+	// top and bottom are the scrolling region, the rows from top inclusive
+	// to bottom exclusive, which is the whole display unless DECSTBM sets
+	// margins.
+	top    int
+	bottom int
+	// wrap is whether a character written in the last column left the
+	// cursor waiting there to wrap before the next, and noAutowrap is
+	// whether DECAWM is reset, so that the next character overwrites the
+	// last column instead.
+	wrap       bool
+	noAutowrap bool
+	// wrapped records, for each row of the display, whether it was
+	// soft-wrapped, that is, whether its text continues on the next row,
+	// for reflow.
+	wrapped []bool
+	pen     pen
+	buf     []byte
+	// last is the last character written, for REP to repeat.
+	last    string
+	history scrollback
+	// primary is the normal screen while the alternate screen is active,
+	// or nil, and primaryWrapped records its soft-wrapped rows.
+	primary        *display.Display
+	primaryWrapped []bool
+	// alt is the alternate screen while the normal screen is active, if the
+	// alternate screen has been used.
+	alt *display.Display
+	// hidden and shape are the visibility and shape of the cursor, which
+	// the display writer reports but does not draw.
+	hidden bool
+	shape  display.CursorShape
+	// responses receives the answers to queries, and err is the first error
+	// writing to it.
+	responses io.Writer
+	err       error
+	// saved holds the cursor that DECSC saves, for the normal and alternate
+	// screens respectively.
+	saved [2]*savedCursor
+}
+
+// savedCursor is the state that DECSC saves and DECRC restores.
+type savedCursor struct {
+	pos image.Point
+	pen pen
+}
+
+// clamp moves the cursor to the nearest cell of the display, and, since
+// clamp follows every cursor movement, cancels a pending wrap.
+func (h *displayWriterHandler) clamp() {
+	h.wrap = false
+	h.pos.X = clamp(h.pos.X, h.rect.Min.X, h.rect.Max.X-1)
+	h.pos.Y = clamp(h.pos.Y, h.rect.Min.Y, h.rect.Max.Y-1)
+}
+
+func clamp(n, min, max int) int {
+	if n > max {
+		n = max
+	}
+	if n < min {
+		n = min
+	}
+	return n
+}
+
+// erase blanks the cells of a rectangle with the current background color.
+// Rows erased in their entirety are no longer soft-wrapped.
+func (h *displayWriterHandler) erase(r image.Rectangle) {
+	r = r.Intersect(h.rect)
+	fg, bg := h.pen.colors(display.Colors[7], display.Colors[0])
+	h.dis.Fill(r, " ", fg, bg)
+	if r.Min.X == h.rect.Min.X && r.Max.X == h.rect.Max.X {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			h.wrapped[y-h.rect.Min.Y] = false
+		}
+	}
 }
 
+// move copies the cells of a rectangle, exactly, to the same size rectangle
+// at another point, in an order that is safe if the rectangles overlap.
+func (h *displayWriterHandler) move(r image.Rectangle, dp image.Point) {
+	copyCells(h.dis, dp, h.dis, r)
+}
+
+// copyCells copies the cells of a rectangle of the source display, exactly,
+// including empty text and transparent colors, to the same size rectangle at
+// a point in the destination display, in an order that is safe if the
+// displays are the same and the rectangles overlap.
+func copyCells(dst *display.Display, dp image.Point, src *display.Display, r image.Rectangle) {
+	d := dp.Sub(r.Min)
+	w, ht := r.Dx(), r.Dy()
+	for j := 0; j < ht; j++ {
+		y := r.Min.Y + j
+		if d.Y > 0 {
+			y = r.Max.Y - 1 - j
+		}
+		for i := 0; i < w; i++ {
+			x := r.Min.X + i
+			if d.X > 0 {
+				x = r.Max.X - 1 - i
+			}
+			dst.Text.Set(x+d.X, y+d.Y, src.Text.At(x, y))
+			dst.Foreground.SetRGBA(x+d.X, y+d.Y, src.Foreground.RGBAAt(x, y))
+			dst.Background.SetRGBA(x+d.X, y+d.Y, src.Background.RGBAAt(x, y))
+			dst.Attributes.Set(x+d.X, y+d.Y, src.Attributes.At(x, y))
+		}
+	}
+}
+
+// scroll moves the rows of the scrolling region up by dy rows, or down for
+// negative dy, blanking the rows that scroll into view.
+// Rows that scroll off the top of the normal screen go to the scrollback.
 func (h *displayWriterHandler) scroll(dy int) {
-	display.Draw(h.dis, h.rect, h.dis, image.Pt(0, dy), draw.Src)
-	h.dis.Clear(image.Rect(0, h.rect.Max.Y-dy, h.rect.Max.X, h.rect.Max.Y))
+	if dy > 0 && h.top == h.rect.Min.Y && h.primary == nil {
+		if n := h.bottom - h.top; dy > n {
+			dy = n
+		}
+		for y := h.top; y < h.top+dy; y++ {
+			line := display.New(image.Rect(0, 0, h.rect.Dx(), 1))
+			copyCells(line, image.ZP, h.dis, image.Rect(h.rect.Min.X, y, h.rect.Max.X, y+1))
+			h.history.push(line, h.wrapped[y-h.rect.Min.Y])
+		}
+	}
+	h.shift(h.top, dy)
+}
+
+// shift moves the rows from top to the bottom of the scrolling region up by dy
+// rows, or down for negative dy, blanking the rows that shift into view and
+// discarding the rows that shift out.
+func (h *displayWriterHandler) shift(top, dy int) {
+	region := image.Rect(h.rect.Min.X, top, h.rect.Max.X, h.bottom)
+	if dy > region.Dy() {
+		dy = region.Dy()
+	} else if dy < -region.Dy() {
+		dy = -region.Dy()
+	}
+	wrapped := h.wrapped[region.Min.Y-h.rect.Min.Y : region.Max.Y-h.rect.Min.Y]
+	if dy > 0 {
+		copy(wrapped, wrapped[dy:])
+	} else if dy < 0 {
+		copy(wrapped[-dy:], wrapped)
+	}
+	if dy > 0 {
+		h.move(image.Rect(region.Min.X, region.Min.Y+dy, region.Max.X, region.Max.Y), region.Min)
+		h.erase(image.Rect(region.Min.X, region.Max.Y-dy, region.Max.X, region.Max.Y))
+	} else if dy < 0 {
+		h.move(image.Rect(region.Min.X, region.Min.Y, region.Max.X, region.Max.Y+dy), image.Pt(region.Min.X, region.Min.Y-dy))
+		h.erase(image.Rect(region.Min.X, region.Min.Y, region.Max.X, region.Min.Y-dy))
+	}
+}
+
+// inRegion returns whether the cursor is within the scrolling region.
+func (h *displayWriterHandler) inRegion() bool {
+	return h.pos.Y >= h.top && h.pos.Y < h.bottom
+}
+
+// lineFeed moves the cursor down a row, scrolling the region up if the cursor
+// is on its bottom row.
+func (h *displayWriterHandler) lineFeed() {
+	if h.pos.Y == h.bottom-1 {
+		h.scroll(1)
+	} else if h.pos.Y < h.rect.Max.Y-1 {
+		h.pos.Y++
+	}
 }
 
 func (h *displayWriterHandler) Flush() error {
 	// fmt.Printf("F %q\r\n", string(h.buf))
-	for _, r := range string(h.buf) {
-		// TODO deal with zero-width-joiner and combined characters
-		if h.pos.X > h.dis.Rect.Max.X {
-			h.pos.X = 0
-			h.pos.Y++
-			if h.pos.Y > h.dis.Rect.Max.Y {
-				h.scroll(1)
-				h.pos.Y--
+	str := string(h.buf)
+	for str != "" {
+		if !utf8.FullRuneInString(str) {
+			// Retain a partial character until the next write completes it.
+			break
+		}
+		// TODO join combining marks that arrive in a later write
+		c, w := textile.Cluster(str)
+		str = str[len(c):]
+		if w == 0 {
+			continue
+		}
+		if w > h.rect.Dx() {
+			continue
+		}
+		if h.wrap || h.pos.X+w > h.rect.Max.X {
+			// Either a pending wrap or a wide character that does not fit
+			// the rest of the row.
+			if h.noAutowrap {
+				h.pos.X = h.rect.Max.X - w
+			} else {
+				h.wrapped[h.pos.Y-h.rect.Min.Y] = true
+				h.pos.X = h.rect.Min.X
+				h.lineFeed()
 			}
+			h.wrap = false
+		}
+		h.last = c
+		fg, bg := h.pen.colors(display.Colors[7], display.Colors[0])
+		h.dis.Set(h.pos.X, h.pos.Y, c, fg, bg)
+		h.dis.SetAttr(h.pos.X, h.pos.Y, h.pen.attr)
+		if w == 2 {
+			h.dis.Set(h.pos.X+1, h.pos.Y, textile.Continuation, fg, bg)
+			h.dis.SetAttr(h.pos.X+1, h.pos.Y, h.pen.attr)
+		}
+		if h.pos.X+w < h.rect.Max.X {
+			h.pos.X += w
+		} else {
+			// Wait in the last column to wrap with the next character.
+			h.pos.X = h.rect.Max.X - 1
+			h.wrap = true
 		}
-		h.dis.Set(h.pos.X, h.pos.Y, string(r), h.fg, h.bg)
-		h.pos.X++
 	}
-	h.buf = h.buf[0:0]
+	h.buf = append(h.buf[0:0], str...)
 
 	return nil
 }
@@ -109,40 +354,50 @@ func (h *displayWriterHandler) Print(b byte) error {
 func (h *displayWriterHandler) Execute(b byte) error {
 	// fmt.Printf("E %q\n", string(b))
 	h.Flush()
+	if b != '\a' {
+		h.wrap = false
+	}
 	switch b {
-	case '\n':
-		h.pos.Y++
+	case '\n', '\v', '\f':
+		h.lineFeed()
 	case '\r':
-		h.pos.X = 0
+		h.pos.X = h.rect.Min.X
 	case '\t':
-		h.pos.X = (h.pos.X + 8) / 8 * 8
+		h.pos.X = (h.pos.X - h.rect.Min.X + 8) / 8 * 8
+		h.pos.X = clamp(h.pos.X+h.rect.Min.X, h.rect.Min.X, h.rect.Max.X-1)
 	case '\b':
-		if h.pos.X > 0 {
+		if h.pos.X > h.rect.Min.X {
 			h.pos.X--
 		}
 	}
 	return nil
 }
 
-// Cursor up
+// Cursor up, stopping at the top margin if the cursor is within the
+// scrolling region.
 func (h *displayWriterHandler) CUU(i int) error {
 	h.Flush()
 	// fmt.Printf("CUU\n")
-	h.pos.Y -= i
-	// if h.pos.Y < h.dis.Rect.Min.Y {
-	// 	h.pos.Y = h.dis.Rect.Min.Y
-	// }
+	min := h.rect.Min.Y
+	if h.inRegion() {
+		min = h.top
+	}
+	h.pos.Y = clamp(h.pos.Y-i, min, h.rect.Max.Y-1)
+	h.clamp()
 	return nil
 }
 
-// Cursor down
+// Cursor down, stopping at the bottom margin if the cursor is within the
+// scrolling region.
 func (h *displayWriterHandler) CUD(i int) error {
 	h.Flush()
 	// fmt.Printf("CUD\n")
-	h.pos.Y += i
-	// if h.pos.Y >= h.dis.Rect.Max.Y {
-	// 	h.pos.Y = h.dis.Rect.Max.Y - 1
-	// }
+	max := h.rect.Max.Y - 1
+	if h.inRegion() {
+		max = h.bottom - 1
+	}
+	h.pos.Y = clamp(h.pos.Y+i, h.rect.Min.Y, max)
+	h.clamp()
 	return nil
 }
 
@@ -151,9 +406,7 @@ func (h *displayWriterHandler) CUF(i int) error {
 	h.Flush()
 	// fmt.Printf("CUF\n")
 	h.pos.X += i
-	// if h.pos.X >= h.dis.Rect.Max.X {
-	// 	h.pos.X = h.dis.Rect.Max.X - 1
-	// }
+	h.clamp()
 	return nil
 }
 
@@ -162,35 +415,38 @@ func (h *displayWriterHandler) CUB(i int) error {
 	h.Flush()
 	// fmt.Printf("CUB\n")
 	h.pos.X -= i
-	// if h.pos.X < h.dis.Rect.Min.X {
-	// 	h.pos.X = h.dis.Rect.Min.X
-	// }
+	h.clamp()
 	return nil
 }
 
-// Cursor new line?
+// Cursor next line
 func (h *displayWriterHandler) CNL(i int) error {
-	h.Flush()
-	// fmt.Printf("CNL\n")
+	h.CUD(i)
+	h.pos.X = h.rect.Min.X
 	return nil
 }
 
-func (h *displayWriterHandler) CPL(int) error {
-	h.Flush()
-	// fmt.Printf("CPL\n")
+// Cursor previous line
+func (h *displayWriterHandler) CPL(i int) error {
+	h.CUU(i)
+	h.pos.X = h.rect.Min.X
 	return nil
 }
 
+// Cursor horizontal absolute
 func (h *displayWriterHandler) CHA(i int) error {
 	h.Flush()
-	h.pos.X = i - 1
+	h.pos.X = h.rect.Min.X + i - 1
+	h.clamp()
 	return nil
 }
 
 // Vertical line position absolute
 func (h *displayWriterHandler) VPA(i int) error {
 	// fmt.Printf("VPA\r\n")
-	h.pos.Y = i - 1
+	h.Flush()
+	h.pos.Y = h.rect.Min.Y + i - 1
+	h.clamp()
 	return nil
 }
 
@@ -198,8 +454,9 @@ func (h *displayWriterHandler) VPA(i int) error {
 func (h *displayWriterHandler) CUP(y, x int) error {
 	// fmt.Printf("CUP\r\n")
 	h.Flush()
-	h.pos.X = x - 1
-	h.pos.Y = y - 1
+	h.pos.X = h.rect.Min.X + x - 1
+	h.pos.Y = h.rect.Min.Y + y - 1
+	h.clamp()
 	return nil
 }
 
@@ -209,7 +466,15 @@ func (h *displayWriterHandler) HVP(y, x int) error {
 	return nil
 }
 
-func (h *displayWriterHandler) DECTCEM(bool) error {
+// Text cursor enable mode, showing or hiding the cursor.
+func (h *displayWriterHandler) DECTCEM(visible bool) error {
+	h.hidden = !visible
+	return nil
+}
+
+// Set cursor style.
+func (h *displayWriterHandler) DECSCUSR(shape int) error {
+	h.shape = display.CursorShape(shape)
 	return nil
 }
 
@@ -221,148 +486,341 @@ func (h *displayWriterHandler) DECCOLM(bool) error {
 	return nil
 }
 
-// Erase display
+// Autowrap mode, which, when reset, leaves the cursor in the last column,
+// overwriting it with each character, instead of wrapping to the next row.
+func (h *displayWriterHandler) DECAWM(set bool) error {
+	h.noAutowrap = !set
+	h.wrap = false
+	return nil
+}
+
+// privateMode sets or resets a DEC private mode, as with CSI ? 1049 h.
+func (h *displayWriterHandler) privateMode(mode int, set bool) {
+	h.Flush()
+	switch mode {
+	case 3:
+		h.DECCOLM(set)
+	case 6:
+		h.DECOM(set)
+	case 7:
+		h.DECAWM(set)
+	case 25:
+		h.DECTCEM(set)
+	case 47, 1047:
+		h.alternateScreen(set)
+	case 1048:
+		if set {
+			h.DECSC()
+		} else {
+			h.DECRC()
+		}
+	case 1049:
+		// Save the cursor and switch to a blank alternate screen, then
+		// switch back and restore the cursor.
+		if set {
+			h.DECSC()
+			h.alternateScreen(true)
+			h.erase(h.rect)
+		} else {
+			h.alternateScreen(false)
+			h.DECRC()
+		}
+	}
+}
+
+// alternateScreen switches between the normal screen and the alternate
+// screen, which has no scrollback.
+// Switching to the alternate screen reveals whatever it held last, until the
+// alternate screen is erased.
+func (h *displayWriterHandler) alternateScreen(set bool) {
+	if set == (h.primary != nil) {
+		return
+	}
+	if set {
+		h.primary, h.dis = h.dis, h.alternate()
+		h.primaryWrapped, h.wrapped = h.wrapped, make([]bool, h.rect.Dy())
+	} else {
+		h.alt, h.dis, h.primary = h.dis, h.primary, nil
+		h.wrapped, h.primaryWrapped = h.primaryWrapped, nil
+	}
+	h.wrap = false
+	h.top, h.bottom = h.rect.Min.Y, h.rect.Max.Y
+}
+
+// alternate returns the alternate screen, as it was when last left.
+func (h *displayWriterHandler) alternate() *display.Display {
+	if h.alt == nil || h.alt.Rect != h.rect {
+		return display.New(h.rect)
+	}
+	return h.alt
+}
+
+// screen returns 0 for the normal screen and 1 for the alternate screen.
+func (h *displayWriterHandler) screen() int {
+	if h.primary != nil {
+		return 1
+	}
+	return 0
+}
+
+// Save cursor position and graphic rendition.
+func (h *displayWriterHandler) DECSC() error {
+	h.Flush()
+	h.saved[h.screen()] = &savedCursor{pos: h.pos, pen: h.pen}
+	return nil
+}
+
+// Restore cursor position and graphic rendition, or go home with the default
+// rendition if none was saved.
+func (h *displayWriterHandler) DECRC() error {
+	h.Flush()
+	if saved := h.saved[h.screen()]; saved != nil {
+		h.pos, h.pen = saved.pos, saved.pen
+	} else {
+		h.pos, h.pen = h.rect.Min, pen{}
+	}
+	h.clamp()
+	return nil
+}
+
+// Erase display: 0 from the cursor to the end, 1 from the beginning to the
+// cursor, 2 everything, 3 the scrollback.
 func (h *displayWriterHandler) ED(i int) error {
 	// fmt.Printf("ED\r\n")
 	h.Flush()
+	r := h.rect
+	switch i {
+	case 0:
+		h.EL(0)
+		h.erase(image.Rect(r.Min.X, h.pos.Y+1, r.Max.X, r.Max.Y))
+	case 1:
+		h.EL(1)
+		h.erase(image.Rect(r.Min.X, r.Min.Y, r.Max.X, h.pos.Y))
+	case 2:
+		h.erase(r)
+	case 3:
+		h.history.clear()
+	}
 	return nil
 }
 
-// Erase line
+// Erase line: 0 from the cursor to the end, 1 from the beginning to the
+// cursor, inclusive, 2 the whole line.
 func (h *displayWriterHandler) EL(i int) error {
 	// fmt.Printf("EL %d\r\n", i)
+	h.Flush()
+	h.wrap = false
+	r := h.rect
 	switch i {
 	case 0:
-		h.dis.Fill(image.Rect(h.pos.X, h.pos.Y, h.rect.Max.X, h.pos.Y+1), " ", h.fg, h.bg)
+		h.erase(image.Rect(h.pos.X, h.pos.Y, r.Max.X, h.pos.Y+1))
 	case 1:
-		h.dis.Fill(image.Rect(0, h.pos.Y, h.pos.X, h.pos.Y+1), " ", h.fg, h.bg)
+		h.erase(image.Rect(r.Min.X, h.pos.Y, h.pos.X+1, h.pos.Y+1))
 	case 2:
-		h.dis.Fill(image.Rect(0, h.pos.Y, h.rect.Max.X, h.pos.Y+1), " ", h.fg, h.bg)
+		h.erase(image.Rect(r.Min.X, h.pos.Y, r.Max.X, h.pos.Y+1))
 	}
 	return nil
 }
 
-// Insert line
-func (h *displayWriterHandler) IL(int) error {
+// Insert line, pushing the lines below the cursor down within the scrolling
+// region.
+func (h *displayWriterHandler) IL(i int) error {
 	// fmt.Printf("IL\r\n")
+	h.Flush()
+	h.wrap = false
+	if !h.inRegion() {
+		return nil
+	}
+	// The lines that shift out are gone, not scrolled into the scrollback.
+	h.shift(h.pos.Y, -i)
+	h.pos.X = h.rect.Min.X
 	return nil
 }
 
-// Delete line
-func (h *displayWriterHandler) DL(int) error {
+// Delete line, pulling the lines below the cursor up within the scrolling
+// region.
+func (h *displayWriterHandler) DL(i int) error {
 	// fmt.Printf("DL\r\n")
+	h.Flush()
+	h.wrap = false
+	if !h.inRegion() {
+		return nil
+	}
+	// The lines that shift out are gone, not scrolled into the scrollback.
+	h.shift(h.pos.Y, i)
+	h.pos.X = h.rect.Min.X
 	return nil
 }
 
-// Insert column
-func (h *displayWriterHandler) ICH(int) error {
+// Insert character, pushing the rest of the line right.
+func (h *displayWriterHandler) ICH(i int) error {
 	// fmt.Printf("ICH\r\n")
+	h.Flush()
+	h.wrap = false
+	i = clamp(i, 0, h.rect.Max.X-h.pos.X)
+	h.move(image.Rect(h.pos.X, h.pos.Y, h.rect.Max.X-i, h.pos.Y+1), image.Pt(h.pos.X+i, h.pos.Y))
+	h.erase(image.Rect(h.pos.X, h.pos.Y, h.pos.X+i, h.pos.Y+1))
 	return nil
 }
 
-// Delete column
-func (h *displayWriterHandler) DCH(int) error {
+// Delete character, pulling the rest of the line left.
+func (h *displayWriterHandler) DCH(i int) error {
 	// fmt.Printf("DCH\r\n")
+	h.Flush()
+	h.wrap = false
+	i = clamp(i, 0, h.rect.Max.X-h.pos.X)
+	h.move(image.Rect(h.pos.X+i, h.pos.Y, h.rect.Max.X, h.pos.Y+1), h.pos)
+	h.erase(image.Rect(h.rect.Max.X-i, h.pos.Y, h.rect.Max.X, h.pos.Y+1))
 	return nil
 }
 
-// Set graphics rendition
-func (h *displayWriterHandler) SGR(codes []int) error {
-	// fmt.Printf("SGR %#v\r\n", codes)
+// Erase character, blanking cells from the cursor without moving the rest of
+// the line.
+func (h *displayWriterHandler) ECH(i int) error {
 	h.Flush()
-
-	if len(codes) == 0 {
-		h.fg = display.Colors[7]
-		h.bg = display.Colors[0]
-	}
-
-	for len(codes) > 0 {
-		code := codes[0]
-		codes = codes[1:]
-		switch {
-
-		case code == 0: // reset
-			h.fg = display.Colors[7]
-			h.bg = display.Colors[0]
-		case code == 1: // TODO high intensity, right?
-
-		case code >= 30 && code < 38: // set foreground color
-			h.fg = display.Colors[code-30]
-		case code >= 90 && code < 98: // set high intensity foreground color
-			h.fg = display.Colors[code-90+8]
-		case code == 39:
-			h.fg = display.Colors[7]
-		case code == 38: // set foreground color
-			h.fg, codes = colorForCodes(codes)
-
-		case code >= 40 && code < 48: // set background color
-			h.bg = display.Colors[code-40]
-		case code >= 100 && code < 108: // set high intensity background color
-			h.bg = display.Colors[code-100+8]
-		case code == 48: // set background color
-			h.bg, codes = colorForCodes(codes)
-		case code == 49:
-			h.bg = display.Colors[0]
-		}
-	}
+	h.wrap = false
+	h.erase(image.Rect(h.pos.X, h.pos.Y, h.pos.X+i, h.pos.Y+1))
 	return nil
 }
 
-func colorForCodes(codes []int) (color.RGBA, []int) {
-	if len(codes) == 0 {
-		return display.Colors[0], codes
+// Repeat the last character written, at most enough times to fill the
+// display.
+func (h *displayWriterHandler) REP(i int) error {
+	h.Flush()
+	if h.last == "" {
+		return nil
 	}
-	code := codes[0]
-	codes = codes[1:]
-	switch {
-	case code == 5:
-		if len(codes) < 1 {
-			return display.Colors[0], codes
-		}
-		return display.Colors[codes[0]], codes[1:]
-	case code == 2:
-		if len(codes) < 3 {
-			return display.Colors[0], codes
-		}
-		return color.RGBA{
-			byte(codes[0]),
-			byte(codes[1]),
-			byte(codes[2]),
-			255,
-		}, codes[3:]
+	i = clamp(i, 0, h.rect.Dx()*h.rect.Dy())
+	for ; i > 0; i-- {
+		h.buf = append(h.buf, h.last...)
 	}
+	return h.Flush()
+}
 
-	return display.Colors[0], codes
+// Set graphics rendition
+func (h *displayWriterHandler) SGR(params [][]int) error {
+	// fmt.Printf("SGR %#v\r\n", params)
+	h.Flush()
+	h.pen.sgr(params)
+	return nil
 }
 
 // Scroll up
-func (h *displayWriterHandler) SU(int) error {
+func (h *displayWriterHandler) SU(i int) error {
 	// fmt.Printf("SU\r\n")
+	h.Flush()
+	h.scroll(i)
 	return nil
 }
 
 // Scroll down
-func (h *displayWriterHandler) SD(int) error {
+func (h *displayWriterHandler) SD(i int) error {
 	// fmt.Printf("SD\r\n")
+	h.Flush()
+	h.scroll(-i)
 	return nil
 }
 
-func (h *displayWriterHandler) DA([]string) error {
+// Device attributes, with the private prefix of the request, like ">" for
+// secondary device attributes.
+// The display writer identifies as a VT220 with ANSI color.
+func (h *displayWriterHandler) DA(prefix byte, params []int) error {
 	// fmt.Printf("DA\r\n")
+	if param(params, 0, 0) != 0 {
+		return nil
+	}
+	switch prefix {
+	case 0:
+		return h.respond("\033[?62;22c")
+	case '>':
+		return h.respond("\033[>1;10;0c")
+	}
+	return nil
+}
+
+// Device status report: 5 for the status of the terminal, 6 for the cursor
+// position, with the private prefix "?" for the DEC form of the cursor
+// position report.
+func (h *displayWriterHandler) DSR(prefix byte, n int) error {
+	h.Flush()
+	switch {
+	case n == 5 && prefix == 0:
+		return h.respond("\033[0n")
+	case n == 6:
+		pos := h.pos.Sub(h.rect.Min)
+		return h.respond(fmt.Sprintf("\033[%s%d;%dR", prefixString(prefix), pos.Y+1, pos.X+1))
+	}
+	return nil
+}
+
+func prefixString(prefix byte) string {
+	if prefix == 0 {
+		return ""
+	}
+	return string(prefix)
+}
+
+// Window manipulation, of which the display writer only reports the size of
+// its text area in cells, for 18.
+func (h *displayWriterHandler) XTWINOPS(params []int) error {
+	if param(params, 0, 0) == 18 {
+		return h.respond(fmt.Sprintf("\033[8;%d;%dt", h.rect.Dy(), h.rect.Dx()))
+	}
 	return nil
 }
 
+// respond writes a response to a query back to the program, if the display
+// writer has a response writer.
+func (h *displayWriterHandler) respond(s string) error {
+	if h.responses == nil {
+		return nil
+	}
+	if _, err := io.WriteString(h.responses, s); err != nil && h.err == nil {
+		h.err = err
+	}
+	return h.err
+}
+
+// Set top and bottom margins of the scrolling region, from 1, inclusive,
+// where a bottom of 0 is the last row.
+// Margins that do not enclose at least two rows reset the region to the
+// whole display.
+// Either way, the cursor returns home.
 func (h *displayWriterHandler) DECSTBM(t, b int) error {
 	// fmt.Printf("DECSTBM %d %d\r\n", t, b)
+	h.Flush()
+	if b == 0 {
+		b = h.rect.Dy()
+	}
+	top := h.rect.Min.Y + t - 1
+	bottom := h.rect.Min.Y + b
+	if top < h.rect.Min.Y || bottom > h.rect.Max.Y || top >= bottom-1 {
+		top, bottom = h.rect.Min.Y, h.rect.Max.Y
+	}
+	h.top, h.bottom = top, bottom
+	h.pos = h.rect.Min
+	h.wrap = false
 	return nil
 }
 
+// Index, moving the cursor down and scrolling at the bottom margin.
 func (h *displayWriterHandler) IND() error {
 	// fmt.Printf("IND\r\n")
+	h.Flush()
+	h.wrap = false
+	h.lineFeed()
 	return nil
 }
 
+// Reverse index, moving the cursor up and scrolling at the top margin.
 func (h *displayWriterHandler) RI() error {
 	// fmt.Printf("RI\r\n")
+	h.Flush()
+	h.wrap = false
+	if h.pos.Y == h.top {
+		h.scroll(-1)
+	} else if h.pos.Y > h.rect.Min.Y {
+		h.pos.Y--
+	}
 	return nil
 }
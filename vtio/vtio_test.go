@@ -0,0 +1,222 @@
+package vtio
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/kriskowal/cops/display"
+	"github.com/stretchr/testify/assert"
+)
+
+// screen writes terminal output to a new display writer and returns the rows
+// of its text, with "." for empty cells.
+func screen(w, h int, out string) []string {
+	vtw := NewDisplayWriter(image.Rect(0, 0, w, h))
+	vtw.Write([]byte(out))
+	return rows(vtw)
+}
+
+func rows(vtw *DisplayWriter) []string {
+	d := vtw.handler.dis
+	var rows []string
+	for y := d.Rect.Min.Y; y < d.Rect.Max.Y; y++ {
+		var row strings.Builder
+		for x := d.Rect.Min.X; x < d.Rect.Max.X; x++ {
+			t := d.Text.At(x, y)
+			if t == "" {
+				t = "."
+			}
+			row.WriteString(t)
+		}
+		rows = append(rows, row.String())
+	}
+	return rows
+}
+
+const abcd = "aaa\r\nbbb\r\nccc\r\nddd"
+
+func TestWrapAndScroll(t *testing.T) {
+	assert.Equal(t, []string{"def", "ghi", "jk "}, screen(3, 3, "abcdefghijk"))
+	assert.Equal(t, []string{"ccc", "ddd", "e  "}, screen(3, 3, abcd+"\r\ne"))
+}
+
+func TestEraseDisplay(t *testing.T) {
+	assert.Equal(t, []string{"aaa", "b  ", "   "}, screen(3, 3, "aaa\r\nbbb\r\nccc\033[2;2H\033[J"))
+	assert.Equal(t, []string{"   ", "  b", "ccc"}, screen(3, 3, "aaa\r\nbbb\r\nccc\033[2;2H\033[1J"))
+	assert.Equal(t, []string{"   ", "   ", "   "}, screen(3, 3, "aaa\r\nbbb\r\nccc\033[2J"))
+}
+
+func TestEraseLine(t *testing.T) {
+	assert.Equal(t, []string{"a  "}, screen(3, 1, "aaa\033[2G\033[K"))
+	assert.Equal(t, []string{"  a"}, screen(3, 1, "aaa\033[2G\033[1K"))
+	assert.Equal(t, []string{"   "}, screen(3, 1, "aaa\033[2K"))
+}
+
+func TestInsertDeleteLine(t *testing.T) {
+	assert.Equal(t, []string{"aaa", "   ", "bbb", "ccc"}, screen(3, 4, abcd+"\033[2;3H\033[L"))
+	assert.Equal(t, []string{"aaa", "ccc", "ddd", "   "}, screen(3, 4, abcd+"\033[2H\033[M"))
+	// Within a scrolling region of rows 2 and 3.
+	assert.Equal(t, []string{"aaa", "   ", "bbb", "ddd"}, screen(3, 4, abcd+"\033[2;3r\033[2H\033[L"))
+	assert.Equal(t, []string{"aaa", "ccc", "   ", "ddd"}, screen(3, 4, abcd+"\033[2;3r\033[2H\033[M"))
+}
+
+func TestInsertDeleteCharacter(t *testing.T) {
+	assert.Equal(t, []string{"a  bc"}, screen(5, 1, "abcde\033[2G\033[2@"))
+	assert.Equal(t, []string{"ade  "}, screen(5, 1, "abcde\033[2G\033[2P"))
+	assert.Equal(t, []string{"a    "}, screen(5, 1, "abcde\033[2G\033[9P"))
+}
+
+func TestScrollUpDown(t *testing.T) {
+	assert.Equal(t, []string{"ccc", "ddd", "   ", "   "}, screen(3, 4, abcd+"\033[2S"))
+	assert.Equal(t, []string{"   ", "aaa", "bbb", "ccc"}, screen(3, 4, abcd+"\033[T"))
+	assert.Equal(t, []string{"aaa", "ccc", "   ", "ddd"}, screen(3, 4, abcd+"\033[2;3r\033[S"))
+}
+
+func TestScrollingRegion(t *testing.T) {
+	// Line feeds at the bottom margin scroll only the region.
+	assert.Equal(t, []string{"top", "222", "333", "bot"},
+		screen(3, 4, "top\r\n\r\n\r\nbot\033[2;3r\033[2H111\r\n222\r\n333"))
+	// Index and reverse index.
+	assert.Equal(t, []string{"aaa", "ccc", "   ", "ddd"}, screen(3, 4, abcd+"\033[2;3r\033[3H\033D"))
+	assert.Equal(t, []string{"aaa", "   ", "bbb", "ddd"}, screen(3, 4, abcd+"\033[2;3r\033[2H\033M"))
+	// Resetting the region.
+	assert.Equal(t, []string{"bbb", "ccc", "ddd", "   "}, screen(3, 4, abcd+"\033[2;3r\033[r\033[4H\n"))
+}
+
+func TestCursorClamp(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 4, 3))
+	vtw.Write([]byte("\033[9;9H"))
+	assert.Equal(t, image.Pt(3, 2), vtw.handler.pos)
+	vtw.Write([]byte("\033[9A\033[9D"))
+	assert.Equal(t, image.Pt(0, 0), vtw.handler.pos)
+	vtw.Write([]byte("\033[9B\033[9C"))
+	assert.Equal(t, image.Pt(3, 2), vtw.handler.pos)
+	vtw.Write([]byte("\033[2F"))
+	assert.Equal(t, image.Pt(0, 0), vtw.handler.pos)
+	vtw.Write([]byte("x\033[E"))
+	assert.Equal(t, image.Pt(0, 1), vtw.handler.pos)
+	// Within a scrolling region, vertical motion stops at the margins.
+	vtw.Write([]byte("\033[2;3r\033[2H\033[9B"))
+	assert.Equal(t, image.Pt(0, 2), vtw.handler.pos)
+	vtw.Write([]byte("\033[9A"))
+	assert.Equal(t, image.Pt(0, 1), vtw.handler.pos)
+}
+
+func TestEraseBackground(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 2, 1))
+	vtw.Write([]byte("\033[44m\033[K"))
+	_, _, b := vtw.handler.dis.At(1, 0)
+	assert.Equal(t, display.Colors[4], b)
+}
+
+func TestParser(t *testing.T) {
+	// UTF-8 text, a window title, and a character set designation.
+	assert.Equal(t, []string{"é世\x00"}, screen(3, 1, "\033]0;tïtle\007é\033(B世"))
+	// A string terminated by ST.
+	assert.Equal(t, []string{"ab."}, screen(3, 1, "a\033]0;title\033\\b"))
+	// Control characters within a control sequence.
+	assert.Equal(t, []string{"a..", ".b."}, screen(3, 2, "a\033[\n2Gb"))
+	// Cancel aborts a sequence.
+	assert.Equal(t, []string{"2Gb"}, screen(3, 1, "\033[\0302Gb"))
+}
+
+func TestParserSplitWrites(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 3, 2))
+	for _, b := range []byte("\033[2;2H世\033[1;1H\033[31ma") {
+		vtw.Write([]byte{b})
+	}
+	assert.Equal(t, []string{"a..", ".世\x00"}, rows(vtw))
+	_, f, _ := vtw.handler.dis.At(0, 0)
+	assert.Equal(t, display.Colors[1], f)
+}
+
+func TestSaveRestoreCursor(t *testing.T) {
+	assert.Equal(t, []string{"ab.", ".c.", "x.."}, screen(3, 3, "a\0337\033[2;2Hc\0338b\033[3Hx"))
+	assert.Equal(t, []string{"ab.", ".c.", "x.."}, screen(3, 3, "a\033[s\033[2;2Hc\033[ub\033[3Hx"))
+
+	vtw := NewDisplayWriter(image.Rect(0, 0, 3, 1))
+	vtw.Write([]byte("\033[32m\0337\033[m\0338x"))
+	_, f, _ := vtw.handler.dis.At(0, 0)
+	assert.Equal(t, display.Colors[2], f, "restores the graphic rendition")
+}
+
+func TestAlternateScreen(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 3, 2))
+	vtw.Write([]byte("$ ls"))
+	vtw.Write([]byte("\033[?1049h\033[Hpg1\r\n\r\npg2"))
+	assert.Equal(t, []string{"   ", "pg2"}, rows(vtw))
+	assert.Equal(t, 0, vtw.Scrollback(), "the alternate screen has no scrollback")
+
+	vtw.Write([]byte("\033[?1049l"))
+	assert.Equal(t, []string{"$ l", "s.."}, rows(vtw))
+	assert.Equal(t, image.Pt(1, 1), vtw.handler.pos, "restores the cursor")
+	vtw.Write([]byte("$"))
+	assert.Equal(t, []string{"$ l", "s$."}, rows(vtw))
+}
+
+func TestAlternateScreenSplitWrites(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 3, 1))
+	in := []byte("ab\033[?1049hx\033[?1049l\033[31mc")
+	for i := range in {
+		n, err := vtw.Write(in[i : i+1])
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+	}
+	assert.Equal(t, []string{"abc"}, rows(vtw))
+	_, f, _ := vtw.handler.dis.At(2, 0)
+	assert.Equal(t, display.Colors[1], f, "passes other sequences to the parser")
+}
+
+func TestAlternateScreenResize(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 2, 1))
+	vtw.Write([]byte("ab\033[?1049hcd"))
+	vtw.Resize(image.Rect(0, 0, 3, 1))
+	vtw.Write([]byte("\033[?1049l"))
+	assert.Equal(t, []string{"ab."}, rows(vtw))
+}
+
+func TestSGRAttributes(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 6, 1))
+	vtw.Write([]byte("\033[1;3ma\033[22;2mb\033[4:3;7mc\033[4:0;27;23md\033[5;9me\033[0mf"))
+	var attrs []display.Attr
+	for x := 0; x < 6; x++ {
+		a := vtw.handler.dis.AttrAt(x, 0)
+		attrs = append(attrs, a)
+	}
+	assert.Equal(t, []display.Attr{
+		display.Bold | display.Italic,
+		display.Dim | display.Italic,
+		display.Dim | display.Italic | display.Underline | display.Reverse,
+		display.Dim,
+		display.Dim | display.Blink | display.Strikethrough,
+		0,
+	}, attrs)
+}
+
+func TestSGRColors(t *testing.T) {
+	vtw := NewDisplayWriter(image.Rect(0, 0, 7, 1))
+	vtw.Write([]byte("\033[31ma\033[1mb\033[38;5;1mc\033[38:5:208;48:2::1:2:3md\033[38:2:4:5:6me\033[38;2;7;8;9;44mf\033[39;49;58:5:1mg"))
+	type colors struct{ f, b color.Color }
+	var got []colors
+	for x := 0; x < 7; x++ {
+		_, f, b := vtw.handler.dis.At(x, 0)
+		got = append(got, colors{f, b})
+	}
+	assert.Equal(t, []colors{
+		{display.Colors[1], display.Colors[0]},
+		{display.Colors[9], display.Colors[0]}, // bold brightens
+		{display.Colors[1], display.Colors[0]}, // but not a 256 color index
+		{display.Colors[208], color.RGBA{1, 2, 3, 255}},
+		{color.RGBA{4, 5, 6, 255}, color.RGBA{1, 2, 3, 255}},
+		{color.RGBA{7, 8, 9, 255}, display.Colors[4]},
+		{display.Colors[7], display.Colors[0]},
+	}, got)
+}
+
+func TestRepeat(t *testing.T) {
+	assert.Equal(t, []string{"abbbb", "....."}, screen(5, 2, "ab\033[3b"))
+	assert.Equal(t, []string{"aaaaa", "aa..."}, screen(5, 2, "a\033[6b"))
+	assert.Equal(t, []string{".....", "....."}, screen(5, 2, "\033[3b"))
+}